@@ -9,37 +9,59 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"runtime"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var c config
 
 func init() {
-	devCmd.PersistentFlags().StringVarP(&c.root, "root", "r", ".",
+	addDevFlags(devCmd.PersistentFlags(), &c)
+}
+
+// addDevFlags binds the flags of fiber dev to c.
+func addDevFlags(flags *pflag.FlagSet, c *config) {
+	flags.StringVar(&c.file, "config", devConfigName,
+		"project config file, flags override its settings")
+	flags.BoolVar(&c.printConfig, "print-config", false,
+		"print the effective configuration and exit")
+	flags.StringVarP(&c.root, "root", "r", ".",
 		"root path for watch, all files must be under root")
-	devCmd.PersistentFlags().StringVarP(&c.target, "target", "t", ".",
+	flags.StringVarP(&c.target, "target", "t", ".",
 		"target path for go build")
-	devCmd.PersistentFlags().StringSliceVarP(&c.extensions, "extensions", "e",
+	flags.StringSliceVarP(&c.extensions, "extensions", "e",
 		[]string{"go", "tmpl", "tpl", "html"}, "file extensions to watch")
-	devCmd.PersistentFlags().StringSliceVarP(&c.excludeDirs, "exclude_dirs", "D",
+	flags.StringSliceVarP(&c.excludeDirs, "exclude_dirs", "D",
 		[]string{"assets", "tmp", "vendor", "node_modules"}, "ignore these directories")
-	devCmd.PersistentFlags().StringSliceVarP(&c.excludeFiles, "exclude_files", "F", nil, "ignore these files")
-	devCmd.PersistentFlags().DurationVarP(&c.delay, "delay", "d", time.Second,
+	flags.StringSliceVarP(&c.excludeFiles, "exclude_files", "F", nil, "ignore these files")
+	flags.StringSliceVar(&c.include, "include", nil,
+		"watch paths matching these glob patterns even if excluded, e.g. .config or config/**/*.yaml")
+	flags.StringSliceVar(&c.exclude, "exclude", nil,
+		"ignore paths matching these glob patterns, e.g. internal/**/*_test.go or web/dist/**")
+	flags.DurationVarP(&c.delay, "delay", "d", time.Second,
 		"delay to trigger rerun")
-	devCmd.PersistentFlags().StringSliceVarP(&c.preRun, "pre-run", "p", nil,
+	flags.StringSliceVarP(&c.preRun, "pre-run", "p", nil,
 		"pre run commands, see example for more detail")
-	devCmd.PersistentFlags().StringSliceVarP(&c.args, "args", "a", nil,
+	flags.StringSliceVarP(&c.args, "args", "a", nil,
 		"arguments for exec")
+	flags.StringSliceVar(&c.buildFlags, "build-flags", nil,
+		"flags for go build e.g:-race,-tags=dev")
+	flags.StringToStringVar(&c.env, "env", nil,
+		"environment variables for go build and the binary e.g:APP_ENV=dev")
+	flags.StringVar(&c.stopSignal, "stop-signal", "SIGTERM",
+		"signal sent to stop the running binary: SIGTERM|SIGINT")
+	flags.DurationVar(&c.gracePeriod, "grace-period", 5*time.Second,
+		"time the binary has to exit after the stop signal before it is killed")
 }
 
 const (
@@ -54,26 +76,46 @@ var devCmd = &cobra.Command{
 	Example: devExample,
 }
 
-func devRunE(_ *cobra.Command, _ []string) error {
+func devRunE(cmd *cobra.Command, _ []string) error {
+	if err := loadDevConfig(cmd.Flags(), &c); err != nil {
+		return err
+	}
+	if c.printConfig {
+		return printDevConfig(cmd.OutOrStdout(), c)
+	}
 	return newEscort(c).run()
 }
 
 type config struct {
+	file         string
 	root         string
 	target       string
 	extensions   []string
 	excludeDirs  []string
 	excludeFiles []string
+	include      []string
+	exclude      []string
 	preRun       []string
+	postBuild    []string
+	postStart    []string
 	args         []string
+	buildFlags   []string
+	env          map[string]string
+	stopSignal   string
 	delay        time.Duration
+	gracePeriod  time.Duration
+	printConfig  bool
 }
 
 type escort struct {
 	ctx        context.Context
 	stdoutPipe io.ReadCloser
 	stderrPipe io.ReadCloser
-	compiling  atomic.Value
+
+	// buildMu serializes the builds, cancelBuild cancels the current one
+	buildMu     sync.Mutex
+	cancelMu    sync.Mutex
+	cancelBuild context.CancelFunc
 
 	terminate context.CancelFunc
 
@@ -87,8 +129,15 @@ type escort struct {
 	hitFunc func()
 
 	binPath string
+	stopSig syscall.Signal
 
-	preRunCommands [][]string
+	// ignoreRules are the rules of the ignore files of the watched directories
+	ignoreRules  []ignoreRule
+	ignoreLoaded map[string]bool
+
+	preRunCommands    [][]string
+	postBuildCommands [][]string
+	postStartCommands [][]string
 
 	config
 
@@ -131,6 +180,10 @@ func (e *escort) run() error {
 	close(e.hitCh)
 	e.wg.Wait()
 
+	if e.bin != nil {
+		e.cleanOldBin()
+	}
+
 	log.Println("See you next time 👋")
 
 	return nil
@@ -147,6 +200,10 @@ func (e *escort) init() error {
 
 	e.ctx, e.terminate = context.WithCancel(context.Background())
 
+	if e.stopSig, err = parseStopSignal(e.stopSignal); err != nil {
+		return err
+	}
+
 	// normalize root
 	if e.root, err = filepath.Abs(e.root); err != nil {
 		return fmt.Errorf("failed to get abs path for root: %w", err)
@@ -172,7 +229,9 @@ func (e *escort) init() error {
 		e.wg.Done()
 	}
 
-	e.preRunCommands = parsePreRunCommands(c.preRun)
+	e.preRunCommands = parsePreRunCommands(e.preRun)
+	e.postBuildCommands = parsePreRunCommands(e.postBuild)
+	e.postStartCommands = parsePreRunCommands(e.postStart)
 
 	return nil
 }
@@ -208,19 +267,19 @@ func (e *escort) watchingFiles() {
 				continue
 			}
 
-			base := filepath.Base(p)
-
-			if info.IsDir() && isCreated(op) {
-				e.walkForWatcher(p)
-				e.hitCh <- struct{}{}
+			if info.IsDir() {
+				if isCreated(op) && !e.ignoredDirs(p) {
+					e.walkForWatcher(p)
+					e.hitCh <- struct{}{}
+				}
 				continue
 			}
 
-			if e.ignoredFiles(base) {
+			if e.ignoredFiles(p) {
 				continue
 			}
 
-			if e.hitExtension(filepath.Ext(base)) {
+			if e.hitExtension(filepath.Ext(p)) || matchAny(e.include, e.rel(p)) {
 				e.hitCh <- struct{}{}
 			}
 		case err := <-e.watcherErrors:
@@ -250,20 +309,31 @@ func (e *escort) watchingBin() {
 	}
 }
 
+// runBin builds the target and replaces the running binary. A build that is
+// still in progress is canceled, so the last call always builds the latest
+// state of the files.
 func (e *escort) runBin() {
-	if ok := e.compiling.Load(); ok != nil {
-		if val, ok := ok.(bool); ok && val {
-			return
-		}
+	ctx, cancel := context.WithCancel(e.ctx)
+	defer cancel()
+
+	e.cancelMu.Lock()
+	if e.cancelBuild != nil {
+		e.cancelBuild()
 	}
+	e.cancelBuild = cancel
+	e.cancelMu.Unlock()
 
-	e.doPreRun()
+	e.buildMu.Lock()
+	defer e.buildMu.Unlock()
 
-	e.compiling.Store(true)
-	defer e.compiling.Store(false)
+	// superseded while waiting for the previous build
+	if ctx.Err() != nil {
+		return
+	}
+
+	e.doPreRun()
 
 	if e.bin != nil {
-		e.cleanOldBin()
 		log.Println("Recompiling...")
 	} else {
 		log.Println("Compiling...")
@@ -271,18 +341,44 @@ func (e *escort) runBin() {
 
 	start := time.Now()
 
-	// build target
-	compile := execCommand("go", "build", "-o", e.binPath, e.target)
-	if out, err := compile.CombinedOutput(); err != nil {
-		log.Printf("Failed to compile %s: %s\n", e.target, out)
+	// build next to the running binary, which keeps serving if the build fails
+	ext := filepath.Ext(e.binPath)
+	next := strings.TrimSuffix(e.binPath, ext) + ".next" + ext
+	compile := execCommand("go", append(append([]string{"build"}, e.buildFlags...), "-o", next, e.target)...)
+	e.setEnv(compile)
+	out, err := combinedOutputContext(ctx, compile)
+	if ctx.Err() != nil || err != nil {
+		switch {
+		case e.ctx.Err() != nil:
+		case ctx.Err() != nil:
+			log.Println("Files changed, canceling the build")
+		default:
+			log.Printf("Failed to compile %s: %s\n", e.target, out)
+			if e.bin != nil {
+				log.Println("Old pid", e.bin.Process.Pid, "keeps running")
+			}
+		}
+		if err := os.Remove(next); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove %s: %v", next, err)
+		}
 		return
 	}
 
 	log.Printf("Compile done in %s!\n", formatLatency(time.Since(start)))
+	e.runCommands("Post build running", e.postBuildCommands)
+
+	if e.bin != nil {
+		e.cleanOldBin()
+	}
+	if err := os.Rename(next, e.binPath); err != nil {
+		log.Printf("Failed to replace bin: %s\n", err)
+		return
+	}
 
 	e.bin = execCommand(e.binPath, e.args...)
 
-	e.bin.Env = os.Environ()
+	e.setEnv(e.bin)
+	setProcessGroup(e.bin)
 
 	e.watchingPipes()
 
@@ -293,29 +389,77 @@ func (e *escort) runBin() {
 	}
 
 	log.Println("New pid is", e.bin.Process.Pid)
+	e.runCommands("Post start running", e.postStartCommands)
 }
 
+// cleanOldBin stops the running binary and the processes it spawned. The
+// stop signal gives it the chance to run its shutdown hooks, if it has not
+// exited after the grace period its process group is killed.
 func (e *escort) cleanOldBin() {
 	pid := e.bin.Process.Pid
-	log.Println("Killing old pid", pid)
+	log.Println("Stopping old pid", pid)
 
-	var err error
-	if runtime.GOOS == windowsOS {
-		err = execCommand("TASKKILL", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+	exited := make(chan struct{})
+	go func() {
+		if _, err := e.bin.Process.Wait(); err != nil {
+			log.Printf("Failed to wait for process %d: %v", pid, err)
+		}
+		close(exited)
+	}()
+
+	graceful := false
+	if err := signalProcessGroup(e.bin.Process, e.stopSig); err != nil {
+		log.Printf("Failed to stop old pid %d: %s\n", pid, err)
 	} else {
-		err = e.bin.Process.Kill()
-		if _, waitErr := e.bin.Process.Wait(); waitErr != nil {
-			log.Printf("Failed to wait for process %d: %v", pid, waitErr)
+		timer := time.NewTimer(e.gracePeriod)
+		select {
+		case <-exited:
+			graceful = true
+		case <-timer.C:
+			log.Printf("Old pid %d did not exit within %s, killing it\n", pid, e.gracePeriod)
 		}
+		timer.Stop()
 	}
 
-	if err != nil {
+	// children that outlive a graceful exit are killed as well
+	if err := killProcessGroup(e.bin.Process); err != nil && !graceful {
 		log.Printf("Failed to kill old pid %d: %s\n", pid, err)
 	}
+	<-exited
 
 	e.bin = nil
 }
 
+// combinedOutputContext runs cmd like CombinedOutput and kills it together
+// with the processes it spawned when ctx is done.
+func combinedOutputContext(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", cmd, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := killProcessGroup(cmd.Process); err != nil {
+				log.Printf("Failed to kill %s: %v", cmd, err)
+			}
+		case <-done:
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		return out.Bytes(), fmt.Errorf("run %s: %w", cmd, err)
+	}
+	return out.Bytes(), nil
+}
+
 func (e *escort) watchingPipes() {
 	var err error
 	if e.stdoutPipe, err = e.bin.StdoutPipe(); err != nil {
@@ -349,11 +493,10 @@ func (e *escort) walkForWatcher(root string) {
 			return nil
 		}
 
-		base := filepath.Base(path)
-
-		if e.ignoredDirs(base) {
+		if e.ignoredDirs(path) {
 			return filepath.SkipDir
 		}
+		e.loadIgnoreFiles(path)
 
 		log.Println("Add", path, "to watch")
 		return e.w.Add(path)
@@ -383,37 +526,112 @@ func (e *escort) hitExtension(ext string) bool {
 	return false
 }
 
+// rel returns the slash separated path of p relative to the root.
+func (e *escort) rel(p string) string {
+	rel, err := filepath.Rel(e.root, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ignoredDirs reports whether the directory is not watched. Hidden
+// directories like .git or .idea, --exclude_dirs, --exclude patterns and the
+// .gitignore and .fiberignore files exclude directories, --include patterns
+// matching the directory or a path inside of it, e.g. .config/**/*.yaml,
+// opt it back in.
 func (e *escort) ignoredDirs(dir string) bool {
-	// exclude hidden directories like .git, .idea, etc.
-	if len(dir) > 1 && dir[0] == '.' {
-		return true
+	rel := e.rel(dir)
+	if rel == "." {
+		return false
+	}
+	if matchAny(e.include, rel) || matchAnyDir(e.include, rel) {
+		return false
 	}
 
+	base := path.Base(rel)
+	if len(base) > 1 && base[0] == '.' {
+		return true
+	}
 	for _, d := range e.excludeDirs {
-		if dir == d {
+		if base == d {
 			return true
 		}
 	}
 
-	return false
+	return matchAny(e.exclude, rel) || ignoredBy(e.ignoreRules, rel, true)
 }
 
+// ignoredFiles reports whether changes of the file are ignored, see
+// ignoredDirs.
 func (e *escort) ignoredFiles(filename string) bool {
+	rel := e.rel(filename)
+	if matchAny(e.include, rel) {
+		return false
+	}
+
+	base := path.Base(rel)
 	for _, f := range e.excludeFiles {
-		if filename == f {
+		if base == f {
 			return true
 		}
 	}
 
-	return false
+	return matchAny(e.exclude, rel) || ignoredBy(e.ignoreRules, rel, false)
+}
+
+// loadIgnoreFiles adds the rules of the ignore files in the directory.
+func (e *escort) loadIgnoreFiles(dir string) {
+	rel := e.rel(dir)
+	if e.ignoreLoaded[rel] {
+		return
+	}
+	if e.ignoreLoaded == nil {
+		e.ignoreLoaded = make(map[string]bool)
+	}
+	e.ignoreLoaded[rel] = true
+
+	for _, name := range ignoreFileNames {
+		b, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304 -- ignore file of a watched directory
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("Failed to read %s: %v", filepath.Join(dir, name), err)
+			}
+			continue
+		}
+		e.ignoreRules = append(e.ignoreRules, parseIgnoreFile(rel, b)...)
+	}
 }
 
 func (e *escort) doPreRun() {
-	for _, command := range e.preRunCommands {
+	e.runCommands("Pre running", e.preRunCommands)
+}
+
+// setEnv adds the variables of --env to the environment of cmd, which
+// defaults to the one of fiber dev.
+func (e *escort) setEnv(cmd *exec.Cmd) {
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	keys := make([]string, 0, len(e.env))
+	for k := range e.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+e.env[k])
+	}
+	cmd.Env = env
+}
+
+func (e *escort) runCommands(action string, commands [][]string) {
+	for _, command := range commands {
 		cmd := execCommand(command[0], command[1:]...)
+		e.setEnv(cmd)
 		out, err := cmd.CombinedOutput()
 		var buf bytes.Buffer
-		if _, werr := buf.WriteString(fmt.Sprintf("Pre running %s... ", command)); werr != nil {
+		if _, werr := buf.WriteString(fmt.Sprintf("%s %s... ", action, command)); werr != nil {
 			log.Printf("Failed to write to buffer: %v", werr)
 		}
 		if err != nil {
@@ -443,6 +661,19 @@ func isChmoded(op fsnotify.Op) bool {
 	return op&fsnotify.Chmod != 0
 }
 
+// parseStopSignal returns the signal of --stop-signal, the SIG prefix is
+// optional.
+func parseStopSignal(name string) (syscall.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "", "TERM":
+		return syscall.SIGTERM, nil
+	case "INT":
+		return syscall.SIGINT, nil
+	default:
+		return 0, fmt.Errorf("invalid stop signal %q, use SIGTERM or SIGINT", name)
+	}
+}
+
 func parsePreRunCommands(commands []string) (list [][]string) {
 	for _, command := range commands {
 		if r := strings.Fields(strings.Trim(command, " ")); len(r) > 0 {
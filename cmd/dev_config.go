@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// devConfigName is the project config file fiber dev loads by default.
+const devConfigName = ".fiber.yaml"
+
+// projectConfig is the content of .fiber.yaml.
+type projectConfig struct {
+	Dev devFileConfig `yaml:"dev"`
+}
+
+// devFileConfig holds the settings of fiber dev, empty values keep the
+// defaults of the flags. Paths are relative to the current directory.
+type devFileConfig struct {
+	Root         string            `yaml:"root,omitempty"`
+	Target       string            `yaml:"target,omitempty"`
+	Extensions   []string          `yaml:"extensions,omitempty"`
+	ExcludeDirs  []string          `yaml:"exclude_dirs,omitempty"`
+	ExcludeFiles []string          `yaml:"exclude_files,omitempty"`
+	Include      []string          `yaml:"include,omitempty"`
+	Exclude      []string          `yaml:"exclude,omitempty"`
+	Args         []string          `yaml:"args,omitempty"`
+	BuildFlags   []string          `yaml:"build_flags,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty"`
+	Hooks        devHooks          `yaml:"hooks,omitempty"`
+	StopSignal   string            `yaml:"stop_signal,omitempty"`
+	Delay        time.Duration     `yaml:"delay,omitempty"`
+	GracePeriod  time.Duration     `yaml:"grace_period,omitempty"`
+}
+
+// devHooks are commands run around every build.
+type devHooks struct {
+	// PreBuild runs before the build, like --pre-run.
+	PreBuild []string `yaml:"pre_build,omitempty"`
+	// PostBuild runs after a successful build, before the binary is replaced.
+	PostBuild []string `yaml:"post_build,omitempty"`
+	// PostStart runs after the new binary started.
+	PostStart []string `yaml:"post_start,omitempty"`
+}
+
+// loadDevConfig applies the settings of the config file c.file to c unless
+// the corresponding flag was set. A missing default file is ignored.
+func loadDevConfig(flags *pflag.FlagSet, c *config) error {
+	if c.file == "" {
+		return nil
+	}
+	b, err := os.ReadFile(filepath.Clean(c.file))
+	if os.IsNotExist(err) && !flags.Changed("config") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read dev config: %w", err)
+	}
+
+	var pc projectConfig
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse %s: %w", c.file, err)
+	}
+	f := pc.Dev
+
+	set := func(flag string, ok bool, apply func()) {
+		if ok && !flags.Changed(flag) {
+			apply()
+		}
+	}
+	set("root", f.Root != "", func() { c.root = f.Root })
+	set("target", f.Target != "", func() { c.target = f.Target })
+	set("extensions", f.Extensions != nil, func() { c.extensions = f.Extensions })
+	set("exclude_dirs", f.ExcludeDirs != nil, func() { c.excludeDirs = f.ExcludeDirs })
+	set("exclude_files", f.ExcludeFiles != nil, func() { c.excludeFiles = f.ExcludeFiles })
+	set("include", f.Include != nil, func() { c.include = f.Include })
+	set("exclude", f.Exclude != nil, func() { c.exclude = f.Exclude })
+	set("args", f.Args != nil, func() { c.args = f.Args })
+	set("build-flags", f.BuildFlags != nil, func() { c.buildFlags = f.BuildFlags })
+	set("pre-run", f.Hooks.PreBuild != nil, func() { c.preRun = f.Hooks.PreBuild })
+	set("stop-signal", f.StopSignal != "", func() { c.stopSignal = f.StopSignal })
+	set("delay", f.Delay != 0, func() { c.delay = f.Delay })
+	set("grace-period", f.GracePeriod != 0, func() { c.gracePeriod = f.GracePeriod })
+	c.postBuild = f.Hooks.PostBuild
+	c.postStart = f.Hooks.PostStart
+
+	// variables of --env override the ones of the file
+	if f.Env != nil {
+		env := maps.Clone(f.Env)
+		maps.Copy(env, c.env)
+		c.env = env
+	}
+
+	return nil
+}
+
+// printDevConfig writes the effective configuration in the format of the
+// config file.
+func printDevConfig(w io.Writer, c config) error {
+	pc := projectConfig{Dev: devFileConfig{
+		Root:         c.root,
+		Target:       c.target,
+		Extensions:   c.extensions,
+		ExcludeDirs:  c.excludeDirs,
+		ExcludeFiles: c.excludeFiles,
+		Include:      c.include,
+		Exclude:      c.exclude,
+		Args:         c.args,
+		BuildFlags:   c.buildFlags,
+		Env:          c.env,
+		Hooks: devHooks{
+			PreBuild:  c.preRun,
+			PostBuild: c.postBuild,
+			PostStart: c.postStart,
+		},
+		StopSignal:  c.stopSignal,
+		Delay:       c.delay,
+		GracePeriod: c.gracePeriod,
+	}}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(pc); err != nil {
+		return fmt.Errorf("encode dev config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode dev config: %w", err)
+	}
+	return nil
+}
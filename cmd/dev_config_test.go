@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDevFlags(t *testing.T, cfg *config, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("dev", pflag.ContinueOnError)
+	addDevFlags(flags, cfg)
+	require.NoError(t, flags.Parse(args))
+	return flags
+}
+
+func Test_Dev_LoadConfig(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), devConfigName)
+	require.NoError(t, os.WriteFile(file, []byte(`dev:
+  target: ./cmd/server
+  root: ./app
+  extensions: [go, html]
+  exclude: ["web/dist/**"]
+  delay: 500ms
+  args: [--port, "3000"]
+  build_flags: [-race]
+  env:
+    APP_ENV: development
+    PORT: "3000"
+  stop_signal: SIGINT
+  hooks:
+    pre_build: [go generate ./...]
+    post_start: [echo started]
+`), 0o600))
+
+	var cfg config
+	flags := newDevFlags(t, &cfg, "--config", file, "-t", "./cmd/api", "--env", "PORT=4000")
+	require.NoError(t, loadDevConfig(flags, &cfg))
+
+	// flags override the file
+	assert.Equal(t, "./cmd/api", cfg.target)
+	assert.Equal(t, map[string]string{"APP_ENV": "development", "PORT": "4000"}, cfg.env)
+
+	assert.Equal(t, "./app", cfg.root)
+	assert.Equal(t, []string{"go", "html"}, cfg.extensions)
+	assert.Equal(t, []string{"assets", "tmp", "vendor", "node_modules"}, cfg.excludeDirs)
+	assert.Equal(t, []string{"web/dist/**"}, cfg.exclude)
+	assert.Equal(t, 500*time.Millisecond, cfg.delay)
+	assert.Equal(t, 5*time.Second, cfg.gracePeriod)
+	assert.Equal(t, []string{"--port", "3000"}, cfg.args)
+	assert.Equal(t, []string{"-race"}, cfg.buildFlags)
+	assert.Equal(t, "SIGINT", cfg.stopSignal)
+	assert.Equal(t, []string{"go generate ./..."}, cfg.preRun)
+	assert.Equal(t, []string{"echo started"}, cfg.postStart)
+
+	var b bytes.Buffer
+	require.NoError(t, printDevConfig(&b, cfg))
+	out := b.String()
+	assert.Contains(t, out, "target: ./cmd/api\n")
+	assert.Contains(t, out, "delay: 500ms\n")
+	assert.Contains(t, out, "PORT: \"4000\"\n")
+	assert.Contains(t, out, "pre_build:\n      - go generate ./...\n")
+}
+
+func Test_Dev_LoadConfig_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// the default file is optional
+	cfg := config{}
+	flags := newDevFlags(t, &cfg)
+	cfg.file = filepath.Join(dir, devConfigName)
+	require.NoError(t, loadDevConfig(flags, &cfg))
+	assert.Equal(t, ".", cfg.target)
+
+	cfg = config{}
+	flags = newDevFlags(t, &cfg, "--config", filepath.Join(dir, "missing.yaml"))
+	require.ErrorContains(t, loadDevConfig(flags, &cfg), "read dev config")
+
+	file := filepath.Join(dir, "typo.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("dev:\n  targt: .\n"), 0o600))
+	cfg = config{}
+	flags = newDevFlags(t, &cfg, "--config", file)
+	require.ErrorContains(t, loadDevConfig(flags, &cfg), "field targt not found")
+}
+
+func Test_Dev_Escort_SetEnv(t *testing.T) {
+	t.Parallel()
+
+	e := getEscort()
+	e.env = map[string]string{"B": "2", "A": "1"}
+
+	cmd := exec.Command("go", "version")
+	cmd.Env = []string{"X=0"}
+	e.setEnv(cmd)
+	assert.Equal(t, []string{"X=0", "A=1", "B=2"}, cmd.Env)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+)
+
+// ignoreFileNames are the files whose patterns fiber dev does not watch.
+var ignoreFileNames = []string{".gitignore", ".fiberignore"}
+
+// globMatch reports whether the slash separated name matches the pattern.
+// Segments are matched with path.Match, a "**" segment matches any number of
+// segments, e.g. internal/**/*_test.go or web/dist/**.
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok { //nolint:errcheck // invalid patterns never match
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// globMatchDir reports whether the pattern can match the slash separated
+// directory name or a path inside of it.
+func globMatchDir(pattern, name string) bool {
+	patterns, names := strings.Split(pattern, "/"), strings.Split(name, "/")
+	for ; len(names) > 0; names = names[1:] {
+		if len(patterns) == 0 {
+			return false
+		}
+		if patterns[0] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(patterns[0], names[0]); !ok { //nolint:errcheck // invalid patterns never match
+			return false
+		}
+		patterns = patterns[1:]
+	}
+	return true
+}
+
+// matchAny reports whether one of the patterns matches the slash separated
+// path relative to the root. Patterns without a slash also match the base
+// name, like the --exclude patterns of fiber migrate.
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if globMatch(pattern, rel) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") && globMatch(pattern, path.Base(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnyDir reports whether one of the patterns can match the slash
+// separated directory relative to the root or a path inside of it. Patterns
+// without a slash only match base names and are left out.
+func matchAnyDir(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") && globMatchDir(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule is a pattern of a .gitignore or .fiberignore file.
+type ignoreRule struct {
+	dir     string // directory of the file relative to the root, "." for the root
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// parseIgnoreFile returns the rules of an ignore file in the directory dir.
+// It supports the gitignore syntax: comments, negation with "!", patterns
+// ending with "/" only match directories and patterns containing a slash are
+// relative to the directory of the file, other patterns match at any depth.
+func parseIgnoreFile(dir string, content []byte) []ignoreRule {
+	var rules []ignoreRule
+	s := bufio.NewScanner(bytes.NewReader(content))
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		r := ignoreRule{dir: dir}
+		if line[0] == '!' {
+			r.negate = true
+			line = line[1:]
+		} else if line[0] == '\\' {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ignoredBy reports whether the slash separated path relative to the root is
+// ignored by the rules. Later rules take precedence, so a negated rule of a
+// nested file re-includes a path its parent ignores.
+func ignoredBy(rules []ignoreRule, rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		name := rel
+		if r.dir != "." {
+			if !strings.HasPrefix(rel, r.dir+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(rel, r.dir+"/")
+		}
+		if globMatch(r.pattern, name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Dev_GlobMatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/app/main.go", true},
+		{"internal/**/*_test.go", "internal/a/b/x_test.go", true},
+		{"internal/**/*_test.go", "internal/x_test.go", true},
+		{"internal/**/*_test.go", "internal/x.go", false},
+		{"web/dist/**", "web/dist", true},
+		{"web/dist/**", "web/dist/js/app.js", true},
+		{"web/dist/**", "web/src/app.js", false},
+		{"[", "[", false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.match, globMatch(tc.pattern, tc.name), "%s %s", tc.pattern, tc.name)
+	}
+}
+
+func Test_Dev_GlobMatchDir(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{".config/**/*.yaml", ".config", true},
+		{".config/**/*.yaml", ".config/app/env", true},
+		{".config/*.yaml", ".config", true},
+		{".config/*.yaml", ".config/app", false},
+		{".config/*.yaml", ".idea", false},
+		{"web/dist", "web", true},
+		{"web/dist", "web/dist", true},
+		{"web/dist", "web/src", false},
+		{"**/*.yaml", ".git", true},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.match, globMatchDir(tc.pattern, tc.name), "%s %s", tc.pattern, tc.name)
+	}
+}
+
+func Test_Dev_IgnoredBy(t *testing.T) {
+	t.Parallel()
+
+	rules := parseIgnoreFile(".", []byte("# comment\n\n*.log\n!keep.log\ndist/\n/bin\n\\!bang\n"))
+	rules = append(rules, parseIgnoreFile("web", []byte("cache\n!debug.log\n"))...)
+
+	cases := []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"dist", true, true},
+		{"dist", false, false},
+		{"web/dist", true, true},
+		{"bin", false, true},
+		{"cmd/bin", false, false},
+		{"!bang", false, true},
+		{"web/cache", true, true},
+		{"cache", true, false},
+		{"web/debug.log", false, false},
+		{"main.go", false, false},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.ignored, ignoredBy(rules, tc.rel, tc.isDir), tc.rel)
+	}
+}
+
+func Test_Dev_Escort_Filters(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	e := getEscort()
+	e.root = t.TempDir()
+	e.excludeDirs = []string{"vendor"}
+	e.include = []string{".config", ".github/**/*.yml"}
+	e.exclude = []string{"internal/**/*_test.go", "web/dist/**"}
+	e.w, err = fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, e.w.Close()) }()
+
+	for _, dir := range []string{".git", ".config", ".github/workflows", ".idea", "vendor", "internal/app", "web/dist/js", "web/src", "tmpdata", "build"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(e.root, dir), 0o750))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(e.root, ".gitignore"), []byte("build/\n*.log\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(e.root, ".fiberignore"), []byte("tmpdata\n"), 0o600))
+
+	e.walkForWatcher(e.root)
+
+	var watched []string
+	for _, p := range e.w.WatchList() {
+		watched = append(watched, e.rel(p))
+	}
+	sort.Strings(watched)
+	assert.Equal(t, []string{".", ".config", ".github", ".github/workflows", "internal", "internal/app", "web", "web/src"}, watched)
+
+	abs := func(rel string) string { return filepath.Join(e.root, filepath.FromSlash(rel)) }
+	assert.True(t, e.ignoredFiles(abs("internal/app/handler_test.go")))
+	assert.False(t, e.ignoredFiles(abs("internal/app/handler.go")))
+	assert.True(t, e.ignoredFiles(abs("server.log")))
+	assert.False(t, e.ignoredFiles(abs(".config/app.go")))
+	assert.False(t, e.ignoredFiles(abs(".github/workflows/ci.yml")))
+}
@@ -77,7 +77,25 @@ func Test_Dev_Escort_RunBin(t *testing.T) {
 	e.stdoutPipe = rc
 	e.stderrPipe = rc
 
+	// the failed build keeps the old binary
+	old := e.bin
 	e.runBin()
+	assert.Same(t, old, e.bin)
+}
+
+func Test_Dev_ParseStopSignal(t *testing.T) {
+	t.Parallel()
+
+	sig, err := parseStopSignal("")
+	require.NoError(t, err)
+	assert.Equal(t, syscall.SIGTERM, sig)
+
+	sig, err = parseStopSignal("int")
+	require.NoError(t, err)
+	assert.Equal(t, syscall.SIGINT, sig)
+
+	_, err = parseStopSignal("SIGHUP")
+	require.ErrorContains(t, err, `invalid stop signal "SIGHUP"`)
 }
 
 func Test_Dev_Escort_WatchingPipes(t *testing.T) {
@@ -316,5 +334,6 @@ func getEscort() *escort {
 		terminate: t,
 		hitCh:     make(chan struct{}, 1),
 		sig:       make(chan os.Signal, 1),
+		stopSig:   syscall.SIGTERM,
 	}
 }
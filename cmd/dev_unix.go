@@ -0,0 +1,30 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the binary in its own process group, so that it
+// and the processes it spawns can be stopped together.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends sig to the process group led by p.
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	if err := syscall.Kill(-p.Pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return fmt.Errorf("send %s to process group %d: %w", sig, p.Pid, err)
+	}
+	return nil
+}
+
+// killProcessGroup kills the process group led by p.
+func killProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
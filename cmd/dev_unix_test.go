@@ -0,0 +1,143 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Dev_Escort_CleanOldBin(t *testing.T) {
+	t.Parallel()
+
+	start := func(t *testing.T, e *escort, script string, args ...string) {
+		t.Helper()
+		e.bin = exec.Command("sh", append([]string{"-c", script}, args...)...)
+		setProcessGroup(e.bin)
+		require.NoError(t, e.bin.Start())
+	}
+
+	t.Run("graceful", func(t *testing.T) {
+		t.Parallel()
+
+		mark := filepath.Join(t.TempDir(), "stopped")
+		e := getEscort()
+		e.gracePeriod = 5 * time.Second
+		start(t, e, `trap 'touch "$0"; exit 0' TERM; while :; do sleep 0.01; done`, mark)
+
+		// give the shell time to install the trap
+		time.Sleep(100 * time.Millisecond)
+		e.cleanOldBin()
+
+		assert.Nil(t, e.bin)
+		assert.FileExists(t, mark)
+	})
+
+	t.Run("kill after grace period", func(t *testing.T) {
+		t.Parallel()
+
+		e := getEscort()
+		e.gracePeriod = 100 * time.Millisecond
+		start(t, e, `trap '' TERM; sleep 30`)
+		pid := e.bin.Process.Pid
+
+		time.Sleep(100 * time.Millisecond)
+		begin := time.Now()
+		e.cleanOldBin()
+
+		assert.Less(t, time.Since(begin), 5*time.Second)
+		// the sleep child is gone together with the shell
+		assert.Eventually(t, func() bool {
+			return errors.Is(syscall.Kill(-pid, 0), syscall.ESRCH)
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func Test_Dev_Escort_RunBin_Swap(t *testing.T) {
+	fail := false
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		if name != "go" {
+			return exec.Command(name, args...)
+		}
+		if fail {
+			return exec.Command("sh", "-c", "echo syntax error; exit 1")
+		}
+		// "go build -o path target" writes a server that runs until stopped
+		return exec.Command("sh", "-c", `printf '#!/bin/sh\nexec sleep 30\n' > "$0" && chmod +x "$0"`, args[2])
+	}
+	defer teardownCmd()
+
+	e := getEscort()
+	e.binPath = filepath.Join(t.TempDir(), "bin")
+	defer func() {
+		if e.bin != nil {
+			e.cleanOldBin()
+		}
+	}()
+
+	e.runBin()
+	require.NotNil(t, e.bin)
+	first := e.bin.Process.Pid
+
+	fail = true
+	e.runBin()
+	require.NotNil(t, e.bin)
+	assert.Equal(t, first, e.bin.Process.Pid)
+	require.NoError(t, syscall.Kill(first, 0))
+	assert.NoFileExists(t, e.binPath+".next")
+
+	fail = false
+	e.runBin()
+	require.NotNil(t, e.bin)
+	assert.NotEqual(t, first, e.bin.Process.Pid)
+	_, err := os.Stat(e.binPath)
+	require.NoError(t, err)
+}
+
+func Test_Dev_Escort_RunBin_Cancel(t *testing.T) {
+	var builds int32
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		if name != "go" {
+			return exec.Command(name, args...)
+		}
+		// the first build hangs until it is canceled
+		if atomic.AddInt32(&builds, 1) == 1 {
+			return exec.Command("sleep", "30")
+		}
+		return exec.Command("sh", "-c", `printf '#!/bin/sh\nexec sleep 30\n' > "$0" && chmod +x "$0"`, args[2])
+	}
+	defer teardownCmd()
+
+	e := getEscort()
+	e.binPath = filepath.Join(t.TempDir(), "bin")
+	defer func() {
+		if e.bin != nil {
+			e.cleanOldBin()
+		}
+	}()
+
+	begin := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.runBin()
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&builds) == 1 }, time.Second, 5*time.Millisecond)
+
+	e.runBin()
+	<-done
+
+	assert.Less(t, time.Since(begin), 10*time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&builds))
+	require.NotNil(t, e.bin)
+	assert.FileExists(t, e.binPath)
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup starts the binary in a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalProcessGroup asks the process tree of p to close, SIGKILL kills it.
+// Windows has no signals, so other values of sig are ignored and the graceful
+// stop is best-effort: taskkill without /F only sends WM_CLOSE to the windows
+// of the tree, console programs have none and keep running until they are
+// killed once the grace period is over.
+func signalProcessGroup(p *os.Process, sig syscall.Signal) error {
+	args := []string{"/T", "/PID", strconv.Itoa(p.Pid)}
+	if sig == syscall.SIGKILL {
+		args = append([]string{"/F"}, args...)
+	}
+	if err := execCommand("TASKKILL", args...).Run(); err != nil {
+		return fmt.Errorf("taskkill %d: %w", p.Pid, err)
+	}
+	return nil
+}
+
+// killProcessGroup forcefully kills the process tree of p.
+func killProcessGroup(p *os.Process) error {
+	return signalProcessGroup(p, syscall.SIGKILL)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gitCommand creates the git commands of the migration, it is replaced in
+// tests.
+var gitCommand = exec.Command
+
+// errNotGitRepo is returned by openGitRepo for directories outside of a git
+// working tree.
+var errNotGitRepo = errors.New("not a git repository")
+
+// gitCommit is a commit made by fiber migrate.
+type gitCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+}
+
+// gitRepo runs git commands in a directory of a working tree. It remembers
+// the commit and branch it was opened at, the index before its first commit
+// and the paths it staged, so that a failed migration can be undone.
+type gitRepo struct {
+	dir    string
+	head   string
+	branch string
+	// index is the tree of the index before the first commit, it keeps the
+	// changes that were staged before the migration
+	index  string
+	staged []string
+}
+
+// openGitRepo returns the repository dir belongs to. It returns errNotGitRepo
+// if git is not installed or dir is not part of a working tree.
+func openGitRepo(dir string) (*gitRepo, error) {
+	g := &gitRepo{dir: dir}
+	if out, err := g.run("rev-parse", "--is-inside-work-tree"); err != nil || strings.TrimSpace(out) != "true" {
+		return nil, errNotGitRepo
+	}
+	// an unborn branch has no HEAD yet
+	if out, err := g.run("rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		g.head = strings.TrimSpace(out)
+	}
+	// a detached HEAD has no branch
+	if out, err := g.run("symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		g.branch = strings.TrimSpace(out)
+	}
+	return g, nil
+}
+
+func (g *gitRepo) run(args ...string) (string, error) {
+	cmd := gitCommand("git", args...) // #nosec G204 -- arguments are controlled
+	cmd.Dir = g.dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// dirty returns the paths below the directory with uncommitted changes,
+// including untracked files, in the short format of git status.
+func (g *gitRepo) dirty() ([]string, error) {
+	out, err := g.run("status", "--porcelain", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// createBranch creates the branch at HEAD and switches to it.
+func (g *gitRepo) createBranch(name string) error {
+	_, err := g.run("checkout", "-b", name)
+	return err
+}
+
+// commit commits the given paths with the message and returns the hash of
+// the commit. Paths that do not exist are left out, nothing is committed if
+// none of them changed.
+func (g *gitRepo) commit(message string, paths ...string) (string, error) {
+	args := []string{"add", "-A", "--"}
+	n := len(args)
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err == nil {
+			args = append(args, p)
+		}
+	}
+	if len(args) == n {
+		return "", nil
+	}
+	if g.index == "" {
+		out, err := g.run("write-tree")
+		if err != nil {
+			return "", err
+		}
+		g.index = strings.TrimSpace(out)
+	}
+	g.staged = append(g.staged, args[n:]...)
+	if _, err := g.run(args...); err != nil {
+		return "", err
+	}
+	if _, err := g.run(append([]string{"diff", "--cached", "--quiet", "--"}, args[n:]...)...); err == nil {
+		return "", nil
+	}
+	if _, err := g.run(append([]string{"commit", "-q", "-m", message, "--"}, args[n:]...)...); err != nil {
+		return "", err
+	}
+	out, err := g.run("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// rollback undoes the commits and staged paths of the migration. The index
+// entries of the staged paths are restored to what they were before the
+// first commit, so changes the user staged beforehand are kept, and the
+// working tree is kept as it is. If branch was created by the migration, the
+// original branch is checked out again and branch is deleted.
+func (g *gitRepo) rollback(branch string) error {
+	if g.head != "" {
+		if _, err := g.run("reset", "-q", "--soft", g.head); err != nil {
+			return err
+		}
+	}
+	if len(g.staged) > 0 {
+		if _, err := g.run(append([]string{"reset", "-q", g.index, "--"}, g.staged...)...); err != nil {
+			return err
+		}
+	}
+	g.staged = nil
+
+	if branch == "" {
+		return nil
+	}
+	orig := g.branch
+	if orig == "" {
+		orig = g.head
+	}
+	if _, err := g.run("checkout", "-q", orig); err != nil {
+		return err
+	}
+	_, err := g.run("branch", "-D", branch)
+	return err
+}
+
+// commit commits the paths changed by the migration step and records the
+// commit. Nothing is recorded if none of the paths changed.
+func (res *migrateResult) commit(repo *gitRepo, stage, description string, paths ...string) error {
+	subject := "fiber migrate: " + stage
+	message := subject
+	if description != "" {
+		message += "\n\n" + description
+	}
+	hash, err := repo.commit(message, paths...)
+	if err != nil {
+		return fmt.Errorf("commit %s: %w", stage, err)
+	}
+	if hash != "" {
+		res.commits = append(res.commits, gitCommit{Hash: hash, Message: subject})
+	}
+	return nil
+}
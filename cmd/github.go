@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	githubAPIURLEnv = "FIBER_GITHUB_API_URL"
+	githubTokenEnv  = "GITHUB_TOKEN"
+)
+
+var latestVersionRegexp = regexp.MustCompile(`"name":\s*?"v(.*?)"`)
+
+// githubRelease is the latest release of a repository cached in
+// .fiberconfig together with the ETag of the response it was read from.
+type githubRelease struct {
+	ETag    string `json:"etag"`
+	Version string `json:"version"`
+}
+
+// githubAPIURL returns the base URL of the GitHub API from the
+// FIBER_GITHUB_API_URL environment variable, the github_api_url setting of
+// .fiberconfig or api.github.com. GitHub Enterprise serves it at
+// https://HOST/api/v3.
+func githubAPIURL() string {
+	u := os.Getenv(githubAPIURLEnv)
+	if u == "" {
+		u = rc.GitHubAPIURL
+	}
+	if u == "" {
+		u = defaultGitHubAPIURL
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// latestGitHubRelease returns the version of the latest release of the
+// repository, e.g. gofiber/fiber, without "v" prefix.
+//
+// Requests are authenticated with GITHUB_TOKEN if it is set. The version is
+// cached in .fiberconfig with the ETag of the response, so later lookups are
+// conditional requests that GitHub answers with 304 Not Modified without
+// counting them against the rate limit. If the rate limit is exceeded anyway,
+// the cached version is returned.
+func latestGitHubRelease(repo string) (string, error) {
+	u := githubAPIURL() + "/repos/" + repo + "/releases/latest"
+	cached, ok := rc.GitHubReleases[u]
+
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv(githubTokenEnv); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if ok && cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
+	}
+
+	res, err := httpGet(u, header)
+	if err != nil {
+		return "", err
+	}
+
+	switch res.status {
+	case http.StatusNotModified:
+		if !ok {
+			return "", errors.New("github responded not modified without cached release")
+		}
+		return cached.Version, nil
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if ok {
+			return cached.Version, nil
+		}
+	}
+
+	submatch := latestVersionRegexp.FindSubmatch(res.body)
+	if len(submatch) != 2 {
+		if res.status != http.StatusOK {
+			return "", fmt.Errorf("github responded with status %d", res.status)
+		}
+		return "", errors.New("no version found in github response body")
+	}
+	v := string(submatch[1])
+
+	if etag := res.header.Get("ETag"); etag != "" && (etag != cached.ETag || v != cached.Version) {
+		if rc.GitHubReleases == nil {
+			rc.GitHubReleases = make(map[string]githubRelease)
+		}
+		rc.GitHubReleases[u] = githubRelease{ETag: etag, Version: v}
+		_ = storeConfig() //nolint:errcheck // the cache is an optimization
+	}
+
+	return v, nil
+}
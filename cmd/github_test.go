@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GitHub_LatestRelease(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceGitHub)
+	t.Setenv(githubAPIURLEnv, "https://ghe.example.com/api/v3/")
+	t.Setenv(githubTokenEnv, "secret")
+
+	origHome, origReleases := homeDir, rc.GitHubReleases
+	homeDir, rc.GitHubReleases = t.TempDir(), nil
+	defer func() { homeDir, rc.GitHubReleases = origHome, origReleases }()
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	const url = "https://ghe.example.com/api/v3/repos/gofiber/fiber/releases/latest"
+	status := http.StatusOK
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+		if req.Header.Get("If-None-Match") == `"abc"` && status == http.StatusOK {
+			return httpmock.NewStringResponse(http.StatusNotModified, ""), nil
+		}
+		res := httpmock.NewBytesResponse(status, fakeVersionResponse)
+		if status != http.StatusOK {
+			res = httpmock.NewStringResponse(status, `{"message": "API rate limit exceeded"}`)
+		}
+		res.Header.Set("ETag", `"abc"`)
+		return res, nil
+	})
+
+	v, err := LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.6", v)
+	assert.Equal(t, githubRelease{ETag: `"abc"`, Version: "2.0.6"}, rc.GitHubReleases[url])
+
+	b, err := os.ReadFile(configFilePath())
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"github_releases"`)
+
+	// revalidated with the ETag
+	rc.GitHubReleases[url] = githubRelease{ETag: `"abc"`, Version: "2.0.7"}
+	v, err = LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.7", v)
+
+	// rate limited
+	status = http.StatusForbidden
+	v, err = LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.7", v)
+
+	rc.GitHubReleases = nil
+	_, err = LatestFiberVersion()
+	require.ErrorContains(t, err, "github responded with status 403")
+}
@@ -7,44 +7,129 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"golang.org/x/mod/modfile"
 )
 
+// goModResult is the outcome of a single go mod command.
+type goModResult struct {
+	Dir     string `json:"dir"`
+	Command string `json:"command"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
 // runGoMod executes `go mod tidy`, `go mod download` and `go mod vendor`
-// inside every directory under root that contains a go.mod file referencing
-// github.com/gofiber/fiber. Directories named `vendor` are skipped.
-func runGoMod(root string) error {
-	dirs, err := fiberModuleDirs(root)
-	if err != nil {
-		return fmt.Errorf("find modules: %w", err)
+// inside every module directory in dirs. If dirs is empty, every directory
+// under root that contains a go.mod file referencing github.com/gofiber/fiber
+// is used. Directories named `vendor` are skipped. If root
+// holds a go.work file, `go work sync` replaces the per module vendoring. It
+// returns the result of every command that was run. If quiet is set, the
+// output of the commands is captured in the results instead of printed.
+func runGoMod(root string, dirs []string, quiet bool) ([]goModResult, error) {
+	if len(dirs) == 0 {
+		var err error
+		if dirs, err = fiberModuleDirs(root); err != nil {
+			return nil, fmt.Errorf("find modules: %w", err)
+		}
 	}
 	commands := [][]string{
 		{"go", "mod", "tidy"},
 		{"go", "mod", "download"},
 		{"go", "mod", "vendor"},
 	}
+
+	// go mod vendor is not supported in workspace mode, the workspace is
+	// synced once all modules are tidy instead
+	workspace := isWorkspace(root)
+	if workspace {
+		commands = commands[:2]
+	}
+
+	var results []goModResult
+	run := func(dir string, args []string) error {
+		cmd := execCommand(args[0], args[1:]...) // #nosec G204 -- commands are controlled
+		cmd.Dir = dir
+		result := goModResult{Dir: dir, Command: strings.Join(args, " ")}
+
+		var err error
+		if quiet {
+			var out []byte
+			out, err = cmd.CombinedOutput()
+			result.Output = string(out)
+		} else {
+			err = runCmd(cmd)
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+
+		if err != nil {
+			return fmt.Errorf("in %s: %w", dir, err)
+		}
+		return nil
+	}
+
 	for _, dir := range dirs {
 		for _, args := range commands {
-			cmd := execCommand(args[0], args[1:]...) // #nosec G204 -- commands are controlled
-			cmd.Dir = dir
-			if err := runCmd(cmd); err != nil {
-				return fmt.Errorf("in %s: %w", dir, err)
+			if err := run(dir, args); err != nil {
+				return results, err
 			}
 		}
 	}
-	return nil
+	if workspace {
+		if err := run(root, []string{"go", "work", "sync"}); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// isWorkspace reports whether root contains a go.work file.
+func isWorkspace(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "go.work"))
+	return err == nil
+}
+
+// fiberModule is a module of the project that requires Fiber.
+type fiberModule struct {
+	version *semver.Version
+	dir     string
+}
+
+// fiberModules returns the modules under root that require Fiber together
+// with the Fiber version each of them requires.
+func fiberModules(root string) ([]fiberModule, error) {
+	dirs, err := fiberModuleDirs(root)
+	if err != nil {
+		return nil, fmt.Errorf("find modules: %w", err)
+	}
+
+	modules := make([]fiberModule, 0, len(dirs))
+	for _, dir := range dirs {
+		version, err := projectFiberVersion(filepath.Join(dir, "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("current fiber project version not found: %w", err)
+		}
+		modules = append(modules, fiberModule{dir: dir, version: version})
+	}
+	return modules, nil
 }
 
 // fiberModuleDirs returns directories under root containing a go.mod file that
-// requires github.com/gofiber/fiber. vendor directories are skipped.
+// requires github.com/gofiber/fiber. Like the go tool, vendor and testdata
+// directories and those starting with "." or "_" are skipped.
 func fiberModuleDirs(root string) ([]string, error) {
 	var dirs []string
 	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() && d.Name() == "vendor" {
-			return filepath.SkipDir
+		if d.IsDir() && path != root {
+			if name := d.Name(); name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
 		}
 		if !d.IsDir() && d.Name() == "go.mod" {
 			b, err := os.ReadFile(path) // #nosec G304 -- reading module file
@@ -56,7 +141,7 @@ func fiberModuleDirs(root string) ([]string, error) {
 				return fmt.Errorf("parse %s: %w", path, err)
 			}
 			for _, r := range mf.Require {
-				if strings.HasPrefix(r.Mod.Path, "github.com/gofiber/fiber") {
+				if fiberModulePathRegexp.MatchString(r.Mod.Path) {
 					dirs = append(dirs, filepath.Dir(path))
 					break
 				}
@@ -0,0 +1,345 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoFile is a parsed Go source file that collects edits against the original
+// source. Edits are applied to the raw bytes instead of re-printing the AST so
+// formatting and comments outside the rewritten nodes are preserved.
+type GoFile struct {
+	Fset *token.FileSet
+	File *ast.File
+
+	added    map[string]string
+	src      []byte
+	edits    []edit
+	notes    []note
+	findings []Finding
+}
+
+// note is a finding recorded at the start of a line of the original source.
+type note struct {
+	message, action string
+	offset          int
+}
+
+type edit struct {
+	text       string
+	start, end int
+}
+
+// Edit replaces the source between Pos and End with Text. It is used to build
+// the source of a node with nested rewrites, see GoFile.SourceWith.
+type Edit struct {
+	Text string
+	Pos  token.Pos
+	End  token.Pos
+}
+
+// ASTProcessor inspects a parsed Go file and records edits on it.
+type ASTProcessor func(f *GoFile)
+
+// ParseGoFile parses src as a Go file including comments.
+func ParseGoFile(filename string, src []byte) (*GoFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+
+	return &GoFile{
+		Fset:  fset,
+		File:  file,
+		src:   src,
+		added: make(map[string]string),
+	}, nil
+}
+
+func (f *GoFile) offset(pos token.Pos) int {
+	return f.Fset.File(pos).Offset(pos)
+}
+
+// Source returns the original source of n.
+func (f *GoFile) Source(n ast.Node) string {
+	return string(f.src[f.offset(n.Pos()):f.offset(n.End())])
+}
+
+// SourceRange returns the original source between start and end.
+func (f *GoFile) SourceRange(start, end token.Pos) string {
+	return string(f.src[f.offset(start):f.offset(end)])
+}
+
+// SourceWith returns the original source of n with the given edits applied.
+// The edits must lie within n and must not overlap.
+func (f *GoFile) SourceWith(n ast.Node, edits ...Edit) string {
+	start := f.offset(n.Pos())
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+
+	var b strings.Builder
+	last := start
+	for _, e := range sorted {
+		s, end := f.offset(e.Pos), f.offset(e.End)
+		b.Write(f.src[last:s])
+		b.WriteString(e.Text)
+		last = end
+	}
+	b.Write(f.src[last:f.offset(n.End())])
+
+	return b.String()
+}
+
+// Replace replaces the source of n with text.
+func (f *GoFile) Replace(n ast.Node, text string) {
+	f.ReplaceRange(n.Pos(), n.End(), text)
+}
+
+// ReplaceRange replaces the source between start and end with text.
+func (f *GoFile) ReplaceRange(start, end token.Pos, text string) {
+	f.edits = append(f.edits, edit{start: f.offset(start), end: f.offset(end), text: text})
+}
+
+// InsertAt inserts text at pos.
+func (f *GoFile) InsertAt(pos token.Pos, text string) {
+	f.ReplaceRange(pos, pos, text)
+}
+
+// Delete removes an element of a comma separated list such as a key-value
+// pair of a composite literal. The trailing comma is removed as well and, if
+// the element is the only one on its line, the whole line is dropped.
+func (f *GoFile) Delete(n ast.Node) {
+	start, end := f.offset(n.Pos()), f.offset(n.End())
+
+	// swallow the trailing comma
+	i := end
+	for i < len(f.src) && (f.src[i] == ' ' || f.src[i] == '\t') {
+		i++
+	}
+	if i < len(f.src) && f.src[i] == ',' {
+		end = i + 1
+	}
+
+	lineStart := bytes.LastIndexByte(f.src[:start], '\n') + 1
+	lineEnd := bytes.IndexByte(f.src[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(f.src) - end
+	}
+	before := bytes.TrimSpace(f.src[lineStart:start])
+	after := bytes.TrimSpace(f.src[end : end+lineEnd])
+	if len(before) == 0 && (len(after) == 0 || bytes.HasPrefix(after, []byte("//"))) {
+		start, end = lineStart, end+lineEnd
+		if end < len(f.src) {
+			end++
+		}
+	} else {
+		for end < len(f.src) && f.src[end] == ' ' {
+			end++
+		}
+	}
+
+	f.edits = append(f.edits, edit{start: start, end: end})
+}
+
+// Report records that the change at n needs manual follow-up. message
+// describes what was changed, action what has to be done by hand.
+func (f *GoFile) Report(n ast.Node, message, action string) {
+	start := f.offset(n.Pos())
+	lineStart := bytes.LastIndexByte(f.src[:start], '\n') + 1
+	f.notes = append(f.notes, note{offset: lineStart, message: message, action: action})
+}
+
+// InsertTODOs inserts a "// TODO(fiber-migrate):" comment above every line
+// with a reported finding.
+func (f *GoFile) InsertTODOs() {
+	for _, n := range f.notes {
+		line := f.src[n.offset:]
+		indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+		text := fmt.Sprintf("%s// TODO(fiber-migrate): %s. %s\n", indent, n.message, n.action)
+		f.edits = append(f.edits, edit{start: n.offset, end: n.offset, text: text})
+	}
+}
+
+// Findings returns the findings reported for the file with line numbers of
+// the source returned by Apply. It must be called after Apply.
+func (f *GoFile) Findings() []Finding {
+	return f.findings
+}
+
+// Changed reports whether edits were recorded.
+func (f *GoFile) Changed() bool {
+	return len(f.edits) > 0
+}
+
+// Apply returns the source with all recorded edits applied.
+func (f *GoFile) Apply() ([]byte, error) {
+	if len(f.edits) == 0 {
+		f.setFindings(f.src)
+		return f.src, nil
+	}
+
+	// insertions sort before replacements starting at the same offset
+	sort.SliceStable(f.edits, func(i, j int) bool {
+		if f.edits[i].start != f.edits[j].start {
+			return f.edits[i].start < f.edits[j].start
+		}
+		return f.edits[i].end < f.edits[j].end
+	})
+
+	var b bytes.Buffer
+	last := 0
+	for _, e := range f.edits {
+		if e.start < last {
+			return nil, fmt.Errorf("overlapping edits at offset %d", e.start)
+		}
+		b.Write(f.src[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(f.src[last:])
+
+	out := b.Bytes()
+	f.setFindings(out)
+
+	return out, nil
+}
+
+// setFindings converts the notes to findings with line numbers of out.
+func (f *GoFile) setFindings(out []byte) {
+	f.findings = f.findings[:0]
+	for _, n := range f.notes {
+		offset := f.newOffset(n.offset)
+		f.findings = append(f.findings, Finding{
+			File:    f.Fset.File(f.File.Pos()).Name(),
+			Line:    bytes.Count(out[:offset], []byte("\n")) + 1,
+			Message: n.message,
+			Action:  n.action,
+		})
+	}
+}
+
+// newOffset maps an offset of the original source to the edited source. The
+// edits must be sorted. Insertions at offset are not counted, so the offset
+// points at the inserted text.
+func (f *GoFile) newOffset(offset int) int {
+	shift := 0
+	for _, e := range f.edits {
+		if e.start >= offset {
+			break
+		}
+		if e.end > offset {
+			return e.start + shift
+		}
+		shift += len(e.text) - (e.end - e.start)
+	}
+	return offset + shift
+}
+
+var majorVersionRegexp = regexp.MustCompile(`^v\d+$`)
+
+// ImportName returns the name under which the import spec is referenced in the
+// file. Blank and dot imports return "_" and "." respectively.
+func ImportName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+
+	p, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+
+	name := path.Base(p)
+	if majorVersionRegexp.MatchString(name) {
+		name = path.Base(path.Dir(p))
+	}
+
+	return name
+}
+
+// ImportPath returns the unquoted path of the import spec.
+func ImportPath(spec *ast.ImportSpec) string {
+	p, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return p
+}
+
+// FindImport returns the first import spec whose path matches.
+func (f *GoFile) FindImport(match func(path string) bool) *ast.ImportSpec {
+	for _, spec := range f.File.Imports {
+		if match(ImportPath(spec)) {
+			return spec
+		}
+	}
+	return nil
+}
+
+// AddImport makes sure importPath is imported and returns the name it is
+// referenced by. Existing imports are reused.
+func (f *GoFile) AddImport(importPath string) string {
+	if spec := f.FindImport(func(p string) bool { return p == importPath }); spec != nil {
+		return ImportName(spec)
+	}
+	if name, ok := f.added[importPath]; ok {
+		return name
+	}
+
+	name := ImportName(&ast.ImportSpec{Path: &ast.BasicLit{Value: strconv.Quote(importPath)}})
+	f.added[importPath] = name
+
+	for _, decl := range f.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Rparen.IsValid() {
+			rparen := f.offset(gen.Rparen)
+			lineStart := bytes.LastIndexByte(f.src[:rparen], '\n') + 1
+			if len(bytes.TrimSpace(f.src[lineStart:rparen])) == 0 {
+				f.edits = append(f.edits, edit{start: lineStart, end: lineStart, text: "\t" + strconv.Quote(importPath) + "\n"})
+			} else {
+				f.InsertAt(gen.Rparen, "\n\t"+strconv.Quote(importPath)+"\n")
+			}
+		} else {
+			f.InsertAt(gen.End(), "\nimport "+strconv.Quote(importPath))
+		}
+		return name
+	}
+
+	f.InsertAt(f.File.Name.End(), "\n\nimport "+strconv.Quote(importPath))
+
+	return name
+}
+
+// DeleteImport removes the import spec from the file.
+func (f *GoFile) DeleteImport(spec *ast.ImportSpec) {
+	for _, decl := range f.File.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, s := range gen.Specs {
+			if s != spec {
+				continue
+			}
+			if gen.Lparen.IsValid() && len(gen.Specs) > 1 {
+				f.Delete(spec)
+			} else {
+				f.Delete(gen)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"context"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GoFile_Edits(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+import "fmt"
+
+var cfg = Config{
+	// keep me
+	A: 1,
+	B: 2, // drop me too
+	C: 3,
+}
+
+func main() { fmt.Println(cfg) }
+`
+	f, err := ParseGoFile("main.go", []byte(src))
+	require.NoError(t, err)
+
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		switch kv.Key.(*ast.Ident).Name { //nolint:forcetypeassert,errcheck // test input
+		case "A":
+			f.Replace(kv.Key, "Renamed")
+		case "B":
+			f.Delete(kv)
+		}
+		return true
+	})
+	assert.Equal(t, "fmt", f.AddImport("fmt"))
+	assert.Equal(t, "strings", f.AddImport("strings"))
+	assert.True(t, f.Changed())
+
+	out, err := f.Apply()
+	require.NoError(t, err)
+	assert.Equal(t, `package main
+
+import "fmt"
+import "strings"
+
+var cfg = Config{
+	// keep me
+	Renamed: 1,
+	C: 3,
+}
+
+func main() { fmt.Println(cfg) }
+`, string(out))
+}
+
+func Test_GoFile_OverlappingEdits(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseGoFile("main.go", []byte("package main\n\nvar a = 1\n"))
+	require.NoError(t, err)
+
+	spec := f.File.Decls[0]
+	f.Replace(spec, "var b = 2")
+	f.Replace(spec, "var c = 3")
+
+	_, err = f.Apply()
+	require.Error(t, err)
+}
+
+func Test_GoFile_Imports(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+import (
+	fiberv2 "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"net/http"
+)
+`
+	f, err := ParseGoFile("main.go", []byte(src))
+	require.NoError(t, err)
+
+	at := assert.New(t)
+	at.Equal("fiberv2", ImportName(f.File.Imports[0]))
+	at.Equal("cors", ImportName(f.File.Imports[1]))
+	at.Equal("github.com/gofiber/fiber/v2/middleware/cors", ImportPath(f.File.Imports[1]))
+	at.Nil(f.FindImport(func(p string) bool { return p == "os" }))
+
+	f.DeleteImport(f.File.Imports[2])
+	at.Equal("os", f.AddImport("os"))
+
+	out, err := f.Apply()
+	require.NoError(t, err)
+	at.Equal(`package main
+
+import (
+	fiberv2 "github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"os"
+)
+`, string(out))
+}
+
+func Test_ChangeFileAST(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.go")
+	invalid := filepath.Join(dir, "invalid.go")
+	require.NoError(t, os.WriteFile(valid, []byte("package main\n\nvar a = 1\n"), 0o600))
+	require.NoError(t, os.WriteFile(invalid, []byte("package main\n\nvar a = \n"), 0o600))
+
+	err := ChangeFileAST(context.Background(), dir, func(f *GoFile) {
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == "a" {
+				f.Replace(id, "b")
+			}
+			return true
+		})
+	})
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(valid) // #nosec G304
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nvar b = 1\n", string(b))
+
+	b, err = os.ReadFile(invalid) // #nosec G304
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nvar a = \n", string(b))
+}
+
+func Test_GoFile_Findings(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+var cfg = Config{
+	A: 1,
+	B: 2,
+}
+`
+	f, err := ParseGoFile("main.go", []byte(src))
+	require.NoError(t, err)
+
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		if kv, ok := n.(*ast.KeyValueExpr); ok {
+			f.Delete(kv)
+			f.Report(kv, "removed "+f.Source(kv.Key), "Do it by hand")
+		}
+		return true
+	})
+	f.AddImport("fmt")
+
+	_, err = f.Apply()
+	require.NoError(t, err)
+
+	findings := f.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, Finding{File: "main.go", Line: 6, Message: "removed A", Action: "Do it by hand"}, findings[0])
+	assert.Equal(t, 6, findings[1].Line)
+
+	f.InsertTODOs()
+	out, err := f.Apply()
+	require.NoError(t, err)
+	assert.Equal(t, `package main
+
+import "fmt"
+
+var cfg = Config{
+	// TODO(fiber-migrate): removed A. Do it by hand
+	// TODO(fiber-migrate): removed B. Do it by hand
+}
+`, string(out))
+	assert.Equal(t, 6, f.Findings()[0].Line)
+	assert.Equal(t, 7, f.Findings()[1].Line)
+}
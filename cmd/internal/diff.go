@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a single line operation. a and b are the positions in the old and
+// new lines before the operation is applied.
+type diffOp struct {
+	kind diffKind
+	a, b int
+}
+
+// UnifiedDiff returns a unified diff between the old and new content of the
+// file name. An empty string is returned if both are equal.
+func UnifiedDiff(name string, oldContent, newContent []byte) string {
+	a, b := splitLines(string(oldContent)), splitLines(string(newContent))
+	ops := myers(a, b)
+
+	var sb strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-diffContext)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != diffEqual {
+				end++
+				continue
+			}
+			j := end
+			for j < len(ops) && ops[j].kind == diffEqual {
+				j++
+			}
+			if j == len(ops) || j-end > 2*diffContext {
+				end = min(end+diffContext, j)
+				break
+			}
+			end = j
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		writeHunk(&sb, a, b, ops[start:end])
+		i = end
+	}
+
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, a, b []string, ops []diffOp) {
+	var aCount, bCount int
+	for _, op := range ops {
+		if op.kind != diffInsert {
+			aCount++
+		}
+		if op.kind != diffDelete {
+			bCount++
+		}
+	}
+	aStart, bStart := ops[0].a, ops[0].b
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			writeDiffLine(sb, ' ', a[op.a])
+		case diffDelete:
+			writeDiffLine(sb, '-', a[op.a])
+		case diffInsert:
+			writeDiffLine(sb, '+', b[op.b])
+		}
+	}
+}
+
+func writeDiffLine(sb *strings.Builder, prefix byte, line string) {
+	sb.WriteByte(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// myers computes the shortest edit script between a and b using the linear
+// space variant of the Myers diff algorithm: the middle snake of an optimal
+// path is searched from both ends and the parts before and after it are
+// diffed recursively, so memory stays O(N+M) for files with many changes.
+// Within a run of changes the deleted lines come before the inserted ones.
+func myers(a, b []string) []diffOp {
+	half := (len(a) + len(b) + 1) / 2
+	d := &differ{
+		a:      a,
+		b:      b,
+		offset: half + 1,
+		vf:     make([]int, 2*half+3),
+		vb:     make([]int, 2*half+3),
+	}
+	d.compare(0, len(a), 0, len(b))
+	groupChanges(d.ops)
+	return d.ops
+}
+
+// groupChanges reorders every run of deletions and insertions in ops so that
+// all deletions of the run come first.
+func groupChanges(ops []diffOp) {
+	for i := 0; i < len(ops); {
+		if ops[i].kind == diffEqual {
+			i++
+			continue
+		}
+		a, b := ops[i].a, ops[i].b
+		deleted, end := 0, i
+		for ; end < len(ops) && ops[end].kind != diffEqual; end++ {
+			if ops[end].kind == diffDelete {
+				deleted++
+			}
+		}
+		for j := i; j < end; j++ {
+			if j-i < deleted {
+				ops[j] = diffOp{kind: diffDelete, a: a + j - i, b: b}
+			} else {
+				ops[j] = diffOp{kind: diffInsert, a: a + deleted, b: b + j - i - deleted}
+			}
+		}
+		i = end
+	}
+}
+
+type differ struct {
+	a, b   []string
+	ops    []diffOp
+	offset int
+	// vf and vb hold the furthest reaching x of the forward and backward
+	// searches per diagonal, they are shared by all calls of compare.
+	vf, vb []int
+}
+
+// compare appends the edit script between a[aLo:aHi] and b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{kind: diffEqual, a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.ops = append(d.ops, diffOp{kind: diffInsert, a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.ops = append(d.ops, diffOp{kind: diffDelete, a: x, b: bLo})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.ops = append(d.ops, diffOp{kind: diffEqual, a: x, b: y})
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.ops = append(d.ops, diffOp{kind: diffEqual, a: aHi + i, b: bHi + i})
+	}
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the middle snake of
+// a shortest edit script between a[aLo:aHi] and b[bLo:bHi].
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	vf, vb, off := d.vf, d.vb, d.offset
+	vf[off+1], vb[off+1] = 0, 0
+
+	for e := 0; e <= (n+m+1)/2; e++ {
+		for k := -e; k <= e; k += 2 {
+			if k == -e || (k != e && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && d.a[aLo+u] == d.b[bLo+v] {
+				u++
+				v++
+			}
+			vf[off+k] = u
+			// the backward search on diagonal delta-k reached e-1 edits
+			if kb := delta - k; odd && kb >= -(e-1) && kb <= e-1 && u+vb[off+kb] >= n {
+				return aLo + x, bLo + y, aLo + u, bLo + v
+			}
+		}
+
+		for k := -e; k <= e; k += 2 {
+			if k == -e || (k != e && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && d.a[aHi-u-1] == d.b[bHi-v-1] {
+				u++
+				v++
+			}
+			vb[off+k] = u
+			if kf := delta - k; !odd && kf >= -e && kf <= e && u+vf[off+kf] >= n {
+				return aHi - u, bHi - v, aHi - x, bHi - y
+			}
+		}
+	}
+
+	// unreachable, the searches meet after at most n+m edits
+	return aLo, bLo, aLo, bLo
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	at.Empty(UnifiedDiff("main.go", []byte("a\nb\n"), []byte("a\nb\n")))
+
+	oldContent := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	newContent := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13"
+	at.Equal(`--- a/main.go
++++ b/main.go
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+\ No newline at end of file
+`, UnifiedDiff("main.go", []byte(oldContent), []byte(newContent)))
+
+	at.Equal("--- a/new.go\n+++ b/new.go\n@@ -0,0 +1,1 @@\n+package main\n",
+		UnifiedDiff("new.go", nil, []byte("package main\n")))
+}
+
+func Test_UnifiedDiff_Replace(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	at.Equal(`--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ a
+-b
++x
+ c
+`, UnifiedDiff("main.go", []byte("a\nb\nc\n"), []byte("a\nx\nc\n")))
+
+	at.Equal(`--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ a
+-b
++x
+ c
+-d
++y
+ e
+`, UnifiedDiff("main.go", []byte("a\nb\nc\nd\ne\n"), []byte("a\nx\nc\ny\ne\n")))
+
+	at.Equal(`--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ a
+-b
+-c
++x
++y
+ d
+`, UnifiedDiff("main.go", []byte("a\nb\nc\nd\n"), []byte("a\nx\ny\nd\n")))
+}
+
+func Test_Myers(t *testing.T) {
+	t.Parallel()
+
+	// lcs returns the length of the longest common subsequence of a and b,
+	// a shortest edit script keeps exactly that many lines
+	lcs := func(a, b []string) int {
+		dp := make([][]int, len(a)+1)
+		for i := range dp {
+			dp[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					dp[i][j] = dp[i+1][j+1] + 1
+				} else {
+					dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+				}
+			}
+		}
+		return dp[0][0]
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test input
+	lines := func() []string {
+		s := make([]string, rnd.IntN(30))
+		for i := range s {
+			s[i] = string(rune('a' + rnd.IntN(4)))
+		}
+		return s
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := lines(), lines()
+		ops := myers(a, b)
+
+		var got []string
+		equal, x, y := 0, 0, 0
+		last := diffEqual
+		for _, op := range ops {
+			require.Equal(t, x, op.a)
+			require.Equal(t, y, op.b)
+			switch op.kind {
+			case diffEqual:
+				require.Equal(t, a[op.a], b[op.b])
+				got = append(got, a[op.a])
+				equal++
+				x++
+				y++
+			case diffDelete:
+				require.NotEqual(t, diffInsert, last, "insertion before deletion")
+				x++
+			case diffInsert:
+				got = append(got, b[op.b])
+				y++
+			}
+			last = op.kind
+		}
+		require.Equal(t, len(a), x)
+		if len(b) == 0 {
+			require.Empty(t, got)
+		} else {
+			require.Equal(t, b, got, "a=%q b=%q", a, b)
+		}
+		require.Equal(t, lcs(a, b), equal, "a=%q b=%q", a, b)
+	}
+}
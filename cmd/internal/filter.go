@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// generatedRegexp matches the header of generated Go files, see
+	// https://go.dev/s/generatedcode.
+	generatedRegexp = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+	packageRegexp   = regexp.MustCompile(`(?m)^package\s`)
+)
+
+// FileFilter decides which files are rewritten by the migrations. testdata
+// directories are always skipped, generated files unless IncludeGenerated is
+// set.
+type FileFilter struct {
+	// Root is the directory the Exclude patterns are relative to. The walked
+	// directory is used if empty.
+	Root string
+	// Exclude holds path.Match patterns matched against the slash separated
+	// path relative to Root and against the base name. Excluding a directory
+	// excludes everything below it.
+	Exclude []string
+	// IncludeGenerated rewrites generated files as well.
+	IncludeGenerated bool
+}
+
+type fileFilterKey struct{}
+
+// WithFileFilter returns a copy of ctx that carries the filter.
+func WithFileFilter(ctx context.Context, f FileFilter) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, fileFilterKey{}, f)
+}
+
+// FileFilterFromContext returns the filter carried by ctx or the default
+// filter.
+func FileFilterFromContext(ctx context.Context) FileFilter {
+	if ctx == nil {
+		return FileFilter{}
+	}
+	f, _ := ctx.Value(fileFilterKey{}).(FileFilter) //nolint:errcheck // zero value if absent
+	return f
+}
+
+// Excluded reports whether path matches one of the exclude patterns. root is
+// used if the filter has no Root.
+func (f FileFilter) Excluded(root, p string) bool {
+	if f.Root != "" {
+		root = f.Root
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	base := path.Base(rel)
+
+	for _, pattern := range f.Exclude {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := path.Match(pattern, rel); ok { //nolint:errcheck // invalid patterns never match
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok { //nolint:errcheck // invalid patterns never match
+			return true
+		}
+	}
+	return false
+}
+
+// IsGenerated reports whether the Go file is generated, either by a
+// "// Code generated ... DO NOT EDIT." line before the package clause or its
+// _gen.go suffix.
+func IsGenerated(name string, content []byte) bool {
+	if strings.HasSuffix(name, "_gen.go") {
+		return true
+	}
+	if loc := packageRegexp.FindIndex(content); loc != nil {
+		content = content[:loc[0]]
+	}
+	return generatedRegexp.Match(content)
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IsGenerated(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+	at.True(IsGenerated("routes_gen.go", []byte("package main\n")))
+	at.True(IsGenerated("main.go", []byte("// Code generated by tool. DO NOT EDIT.\n\npackage main\n")))
+	at.True(IsGenerated("main.go", []byte("// Copyright\n\n// Code generated by tool. DO NOT EDIT.\npackage main\n")))
+	at.False(IsGenerated("main.go", []byte("package main\n\n// Code generated by tool. DO NOT EDIT.\n")))
+	at.False(IsGenerated("main.go", []byte("// Code generated by tool.\npackage main\n")))
+}
+
+func Test_FileFilter_Excluded(t *testing.T) {
+	t.Parallel()
+
+	f := FileFilter{Root: "/project", Exclude: []string{"internal/legacy/", "*_mock.go", "api/*.go"}}
+
+	at := assert.New(t)
+	at.True(f.Excluded("/project/mod", "/project/internal/legacy"))
+	at.True(f.Excluded("/project/mod", "/project/pkg/user_mock.go"))
+	at.True(f.Excluded("/project/mod", "/project/api/routes.go"))
+	at.False(f.Excluded("/project/mod", "/project/api/v1/routes.go"))
+	at.False(f.Excluded("/project/mod", "/project/main.go"))
+
+	at.True(FileFilter{Exclude: []string{"legacy"}}.Excluded("/project", "/project/legacy"))
+}
+
+func Test_ChangeFileContent_Filter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := "package main\n\nimport _ \"github.com/gofiber/fiber/v2\"\n"
+	files := map[string]string{
+		"main.go":                src,
+		"routes_gen.go":          src,
+		"generated.go":           "// Code generated by tool. DO NOT EDIT.\n\n" + src,
+		"testdata/main.go":       src,
+		"internal/legacy/old.go": src,
+		"internal/new.go":        src,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	report := NewReport(false)
+	ctx := WithReport(context.Background(), report)
+	ctx = WithFileFilter(ctx, FileFilter{Exclude: []string{"internal/legacy"}})
+	err := ChangeFileContent(ctx, dir, func(content string) string {
+		return content + "// migrated\n"
+	})
+	require.NoError(t, err)
+
+	for name, content := range files {
+		b, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304
+		require.NoError(t, err)
+		if name == "main.go" || name == "internal/new.go" {
+			assert.Equal(t, content+"// migrated\n", string(b), name)
+		} else {
+			assert.Equal(t, content, string(b), name)
+		}
+	}
+
+	findings := report.Findings()
+	require.Len(t, findings, 2)
+	for _, f := range findings {
+		assert.Equal(t, "skipped generated file", f.Message)
+	}
+
+	// generated files are migrated on request
+	ctx = WithFileFilter(context.Background(), FileFilter{IncludeGenerated: true})
+	require.NoError(t, ChangeFileContent(ctx, dir, func(content string) string {
+		return content + "// again\n"
+	}))
+	b, err := os.ReadFile(filepath.Join(dir, "routes_gen.go")) // #nosec G304
+	require.NoError(t, err)
+	assert.Equal(t, src+"// again\n", string(b))
+}
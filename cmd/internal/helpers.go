@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/containerd/console"
@@ -32,37 +35,202 @@ func checkConsole() (size console.WinSize, err error) {
 type FileProcessor func(content string) string
 
 // ChangeFileContent walks through cwd and applies the processorFn to every Go
-// file found. Files in a vendor directory are skipped.
-func ChangeFileContent(cwd string, processorFn FileProcessor) error {
-	err := filepath.Walk(cwd, func(path string, info os.FileInfo, err error) error {
+// file found. Files in vendor and testdata directories or a nested module are
+// skipped, as are generated files and files excluded by the FileFilter
+// carried by ctx. If ctx carries an
+// Overlay, files are read from and written to it instead of disk. If ctx
+// carries a Pipeline for cwd, the processorFn is added to it and runs when
+// the pipeline is run.
+func ChangeFileContent(ctx context.Context, cwd string, processorFn FileProcessor) error {
+	if p := PipelineFromContext(ctx); p != nil && p.add(cwd, transform{text: processorFn}) {
+		return nil
+	}
+	return walkGoFiles(ctx, cwd, func(_ string, content []byte) ([]byte, error) {
+		return []byte(processorFn(string(content))), nil
+	})
+}
+
+// ChangeFileAST walks through cwd, parses every Go file found and applies the
+// processorFn to it. Files that cannot be parsed are left untouched and only
+// files with recorded edits are written back. Findings are added to the Report
+// carried by ctx. Like ChangeFileContent, the processorFn is deferred to the
+// Pipeline carried by ctx.
+func ChangeFileAST(ctx context.Context, cwd string, processorFn ASTProcessor) error {
+	if p := PipelineFromContext(ctx); p != nil && p.add(cwd, transform{ast: processorFn}) {
+		return nil
+	}
+	return walkGoFiles(ctx, cwd, func(path string, content []byte) ([]byte, error) {
+		f, err := ParseGoFile(path, content)
+		if err != nil {
+			return content, nil //nolint:nilerr // not valid Go, nothing to migrate
+		}
+
+		processorFn(f)
+
+		r := ReportFromContext(ctx)
+		if r != nil && r.TODOs() {
+			f.InsertTODOs()
+		}
+
+		out, err := f.Apply()
 		if err != nil {
+			return nil, fmt.Errorf("apply edits to %s: %w", path, err)
+		}
+		if r != nil {
+			r.Add(f.Findings()...)
+		}
+		return out, nil
+	})
+}
+
+// FilesProcessor processes the content of all Go files of a module at once,
+// keyed by path, and returns the files it changed.
+type FilesProcessor func(files map[string][]byte) (map[string][]byte, error)
+
+// ChangeFiles loads the Go files below cwd that ChangeFileContent would
+// process and passes them to processorFn at once. Transforms queued in the
+// Pipeline carried by ctx run first, so that processorFn sees their result.
+// Only changed files are written back, processorFn may not add files.
+func ChangeFiles(ctx context.Context, cwd string, processorFn FilesProcessor) error {
+	if p := PipelineFromContext(ctx); p != nil {
+		if err := p.Run(ctx); err != nil {
 			return err
 		}
+	}
 
-		// Skip directories named "vendor"
-		if info.IsDir() && info.Name() == "vendor" {
-			return filepath.SkipDir
+	paths, err := goFiles(ctx, cwd)
+	if err != nil {
+		return err
+	}
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		content, ok, err := loadGoFile(ctx, path, "")
+		if err != nil {
+			return err
 		}
+		if ok {
+			files[path] = content
+		}
+	}
 
-		// Check if the file is a Go file (ending with ".go")
-		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
-			return nil
+	changed, err := processorFn(files)
+	if err != nil {
+		return err
+	}
+
+	changedPaths := make([]string, 0, len(changed))
+	for path := range changed {
+		if _, ok := files[path]; !ok {
+			return fmt.Errorf("%s is not a Go file of %s", path, cwd)
+		}
+		changedPaths = append(changedPaths, path)
+	}
+	sort.Strings(changedPaths)
+	for _, path := range changedPaths {
+		if bytes.Equal(changed[path], files[path]) {
+			continue
 		}
-		fileContent, err := os.ReadFile(path) // #nosec G304
+		if err := WriteFile(ctx, path, changed[path]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkGoFiles(ctx context.Context, cwd string, fn func(path string, content []byte) ([]byte, error)) error {
+	paths, err := goFiles(ctx, cwd)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fileContent, ok, err := loadGoFile(ctx, path, "")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		newContent, err := fn(path, fileContent)
 		if err != nil {
-			return fmt.Errorf("read file %s: %w", path, err)
+			return err
+		}
+		if bytes.Equal(newContent, fileContent) {
+			continue
 		}
 
-		// update go.mod file
-		if err2 := os.WriteFile(path, []byte(processorFn(string(fileContent))), 0o600); err2 != nil {
-			return fmt.Errorf("write file %s: %w", path, err2)
+		if err := WriteFile(ctx, path, newContent); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// goFiles returns the Go files below cwd that are not skipped by the
+// FileFilter carried by ctx.
+func goFiles(ctx context.Context, cwd string) ([]string, error) {
+	filter := FileFilterFromContext(ctx)
 
+	var paths []string
+	err := filepath.Walk(cwd, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && path != cwd {
+			// Skip directories named "vendor" or "testdata"
+			if info.Name() == "vendor" || info.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+			// Nested modules are migrated on their own
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			if filter.Excluded(cwd, path) {
+				return filepath.SkipDir
+			}
+		}
+
+		// Check if the file is a Go file (ending with ".go")
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+		if filter.Excluded(cwd, path) {
+			return nil
+		}
+		paths = append(paths, path)
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("error while traversing the directory tree: %w", err)
+		return nil, fmt.Errorf("error while traversing the directory tree: %w", err)
 	}
 
-	return nil
+	return paths, nil
+}
+
+// loadGoFile reads the Go file. It reports false for generated files that
+// are not migrated, stage is the migration step reporting the skipped file or
+// empty for the current stage of the Report.
+func loadGoFile(ctx context.Context, path, stage string) ([]byte, bool, error) {
+	content, err := ReadFile(ctx, path)
+	if err != nil {
+		return nil, false, err
+	}
+	if FileFilterFromContext(ctx).IncludeGenerated || !IsGenerated(filepath.Base(path), content) {
+		return content, true, nil
+	}
+
+	if r := ReportFromContext(ctx); r != nil && bytes.Contains(content, []byte("github.com/gofiber/fiber")) {
+		r.Add(Finding{
+			File:      path,
+			Migration: stage,
+			Line:      1,
+			Message:   "skipped generated file",
+			Action:    "Regenerate it for the new Fiber version or migrate it with --include-generated",
+		})
+	}
+	return nil, false, nil
 }
@@ -2,7 +2,7 @@ package migrations
 
 import (
 	"fmt"
-	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,37 +13,65 @@ import (
 	"github.com/gofiber/cli/cmd/internal"
 )
 
-var pkgRegex = regexp.MustCompile(`(github\.com\/gofiber\/fiber\/)(v\d+)( *?)(v[\w.-]+)`)
+// pkgRegex matches the Fiber requirement of a go.mod file. Fiber v1 has no
+// major version suffix.
+var pkgRegex = regexp.MustCompile(`(github\.com\/gofiber\/fiber)(\/v\d+)?([ \t]+)(v[\w.+-]+)`)
 
-func MigrateGoPkgs(cmd *cobra.Command, cwd string, curr, target *semver.Version) error {
-	pkgReplacer := strings.NewReplacer(
-		"github.com/gofiber/fiber/v"+strconv.FormatUint(curr.Major(), 10),
-		"github.com/gofiber/fiber/v"+strconv.FormatUint(target.Major(), 10),
-	)
+// importRegex matches quoted Fiber import paths, the group holds the path
+// after the module root including a major version suffix.
+var importRegex = regexp.MustCompile(`"github\.com/gofiber/fiber((?:/[^"]*)?)"`)
+
+var majorSuffixRegex = regexp.MustCompile(`^/v\d+(/|$)`)
+
+// modulePath returns the Fiber module path of the major version.
+func modulePath(major uint64) string {
+	if major < 2 {
+		return "github.com/gofiber/fiber"
+	}
+	return "github.com/gofiber/fiber/v" + strconv.FormatUint(major, 10)
+}
 
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return pkgReplacer.Replace(content)
+// replaceImports replaces the Fiber import paths of the curr major version
+// by those of the target major version.
+func replaceImports(content string, curr, target uint64) string {
+	if curr >= 2 {
+		return strings.ReplaceAll(content, modulePath(curr), modulePath(target))
+	}
+	// v1 import paths have no suffix to replace, the paths of the newer
+	// majors must be left alone
+	return importRegex.ReplaceAllStringFunc(content, func(m string) string {
+		sub := importRegex.FindStringSubmatch(m)[1]
+		if majorSuffixRegex.MatchString(sub) {
+			return m
+		}
+		return `"` + modulePath(target) + sub + `"`
+	})
+}
+
+func MigrateGoPkgs(cmd *cobra.Command, cwd string, curr, target *semver.Version) error {
+	err := internal.ChangeFileContent(cmd.Context(), cwd, func(content string) string {
+		return replaceImports(content, curr.Major(), target.Major())
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate Go packages: %w", err)
 	}
 
 	// get go.mod file
-	modFile := "go.mod"
-	fileContent, err := os.ReadFile(modFile)
+	modFile := filepath.Join(cwd, "go.mod")
+	fileContent, err := internal.ReadFile(cmd.Context(), modFile)
 	if err != nil {
-		return fmt.Errorf("read %s: %w", modFile, err)
+		return err
 	}
 
 	// replace old version with new version in go.mod file
 	fileContentStr := pkgRegex.ReplaceAllString(
 		string(fileContent),
-		"${1}v"+strconv.FormatUint(target.Major(), 10)+"${3}v"+target.String(),
+		modulePath(target.Major())+"${3}v"+target.String(),
 	)
 
 	// update go.mod file
-	if err := os.WriteFile(modFile, []byte(fileContentStr), 0o600); err != nil {
-		return fmt.Errorf("write %s: %w", modFile, err)
+	if err := internal.WriteFile(cmd.Context(), modFile, []byte(fileContentStr)); err != nil {
+		return err
 	}
 
 	cmd.Println("Migrating Go packages")
@@ -0,0 +1,8 @@
+package migrations
+
+// ResetRegistered removes the migrations added by Register.
+func ResetRegistered() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registered = nil
+}
@@ -0,0 +1,231 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+// ExternalPrefix is the name prefix of executables that provide migrations.
+const ExternalPrefix = "fiber-migrate-"
+
+const describeTimeout = 10 * time.Second
+
+// externalDescription is printed by "<executable> describe".
+type externalDescription struct {
+	Description string `json:"description"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+}
+
+// externalFile is a Go file exchanged with an external migration, Path is
+// relative to the module and slash separated.
+type externalFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// externalRequest is written to the stdin of "<executable> migrate".
+type externalRequest struct {
+	Module string         `json:"module"`
+	From   string         `json:"from"`
+	To     string         `json:"to"`
+	Files  []externalFile `json:"files"`
+}
+
+// externalFinding is a change that needs manual follow-up.
+type externalFinding struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Action  string `json:"action,omitempty"`
+	Line    int    `json:"line"`
+}
+
+// externalResponse is read from the stdout of "<executable> migrate".
+type externalResponse struct {
+	Files    []externalFile    `json:"files"`
+	Findings []externalFinding `json:"findings"`
+}
+
+// LoadExternal discovers the fiber-migrate-* executables in the directories
+// of pathList and makes them available as migrations named after the
+// executable. They replace the external migrations of a previous call, an
+// empty pathList removes them. Like the shell, only the first executable of a
+// name is used. Executables whose describe call fails are left out and
+// returned as warnings.
+//
+// An executable prints a JSON object with "description", "from" and "to"
+// constraints when called with "describe". Called with "migrate", it reads the
+// module directory, versions and Go files as JSON from stdin and writes the
+// changed files and findings as JSON to stdout.
+func LoadExternal(ctx context.Context, pathList string) ([]error, error) {
+	paths := findExternal(pathList)
+
+	ms := make([]Migration, 0, len(paths))
+	var warnings []error
+	for _, path := range paths {
+		m, err := describeExternal(ctx, path)
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		ms = append(ms, m)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	prev := external
+	external = ms
+	if err := checkNames(all()); err != nil {
+		external = prev
+		return warnings, err
+	}
+	return warnings, nil
+}
+
+func findExternal(pathList string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := externalName(e.Name())
+			if !strings.HasPrefix(name, ExternalPrefix) || name == ExternalPrefix || seen[name] || e.IsDir() {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil || (runtime.GOOS != "windows" && info.Mode()&0o111 == 0) {
+				continue
+			}
+			seen[name] = true
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return externalName(filepath.Base(paths[i])) < externalName(filepath.Base(paths[j]))
+	})
+	return paths
+}
+
+func externalName(file string) string {
+	if runtime.GOOS == "windows" {
+		return strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	return file
+}
+
+func describeExternal(ctx context.Context, path string) (Migration, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "describe").Output() // #nosec G204
+	if err != nil {
+		return Migration{}, fmt.Errorf("describe %s: %w", path, err)
+	}
+	var d externalDescription
+	if err := json.Unmarshal(out, &d); err != nil {
+		return Migration{}, fmt.Errorf("describe %s: %w", path, err)
+	}
+
+	name := externalName(filepath.Base(path))
+	m := Migration{
+		From:         d.From,
+		To:           d.To,
+		Functions:    []MigrationFn{externalMigration(path)},
+		Names:        []string{name},
+		Descriptions: map[string]string{name: d.Description},
+	}
+	if m.From == "" {
+		m.From = ">=0.0.0-0"
+	}
+	if m.To == "" {
+		m.To = ">=0.0.0-0"
+	}
+	if _, _, err := m.constraints(); err != nil {
+		return Migration{}, fmt.Errorf("describe %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// externalMigration runs the executable on the Go files of the module.
+func externalMigration(path string) MigrationFn {
+	return func(cmd *cobra.Command, cwd string, curr, target *semver.Version) error {
+		name := externalName(filepath.Base(path))
+		cmd.Printf("Running %s\n", name)
+
+		err := internal.ChangeFiles(cmd.Context(), cwd, func(files map[string][]byte) (map[string][]byte, error) {
+			req := externalRequest{Module: cwd, From: curr.String(), To: target.String()}
+			for p, content := range files {
+				rel, err := filepath.Rel(cwd, p)
+				if err != nil {
+					return nil, fmt.Errorf("relative path of %s: %w", p, err)
+				}
+				req.Files = append(req.Files, externalFile{Path: filepath.ToSlash(rel), Content: string(content)})
+			}
+			sort.Slice(req.Files, func(i, j int) bool { return req.Files[i].Path < req.Files[j].Path })
+
+			in, err := json.Marshal(req)
+			if err != nil {
+				return nil, fmt.Errorf("encode request: %w", err)
+			}
+
+			var stdout bytes.Buffer
+			c := exec.CommandContext(cmd.Context(), path, "migrate") // #nosec G204
+			c.Dir = cwd
+			c.Stdin = bytes.NewReader(in)
+			c.Stdout = &stdout
+			c.Stderr = cmd.ErrOrStderr()
+			if err := c.Run(); err != nil {
+				return nil, fmt.Errorf("run %s: %w", path, err)
+			}
+
+			var resp externalResponse
+			if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+				return nil, fmt.Errorf("decode output of %s: %w", path, err)
+			}
+
+			changed := make(map[string][]byte, len(resp.Files))
+			for _, f := range resp.Files {
+				changed[filepath.Join(cwd, filepath.FromSlash(f.Path))] = []byte(f.Content)
+			}
+			if r := internal.ReportFromContext(cmd.Context()); r != nil {
+				for _, f := range resp.Findings {
+					r.Add(internal.Finding{
+						File:    filepath.Join(cwd, filepath.FromSlash(f.Path)),
+						Line:    f.Line,
+						Message: f.Message,
+						Action:  f.Action,
+					})
+				}
+			}
+			return changed, nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal"
+	"github.com/gofiber/cli/cmd/internal/migrations"
+)
+
+// the test binary acts as external migration if started with this variable
+const helperEnv = "FIBER_MIGRATE_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		os.Exit(externalHelper(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// externalHelper renames oldName to newName.
+func externalHelper(args []string) int {
+	if len(args) == 0 {
+		return 2
+	}
+
+	switch args[0] {
+	case "describe":
+		fmt.Println(`{"description": "Rename oldName to newName", "from": ">=2.0.0", "to": "<4.0.0-0"}`)
+		return 0
+	case "migrate":
+		var req struct {
+			Files []struct {
+				Path    string `json:"path"`
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		resp := map[string][]map[string]any{"files": {}, "findings": {}}
+		for _, f := range req.Files {
+			if !strings.Contains(f.Content, "oldName") {
+				continue
+			}
+			resp["files"] = append(resp["files"], map[string]any{"path": f.Path, "content": strings.ReplaceAll(f.Content, "oldName", "newName")})
+			resp["findings"] = append(resp["findings"], map[string]any{"path": f.Path, "line": 3, "message": "renamed oldName"})
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+			return 1
+		}
+		return 0
+	default:
+		return 2
+	}
+}
+
+func Test_External(t *testing.T) {
+	t.Setenv(helperEnv, "1")
+	t.Cleanup(migrations.ResetRegistered)
+	t.Cleanup(func() {
+		_, err := migrations.LoadExternal(context.Background(), "")
+		require.NoError(t, err)
+	})
+
+	at := assert.New(t)
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	bin := t.TempDir()
+	require.NoError(t, os.Symlink(exe, filepath.Join(bin, "fiber-migrate-rename")))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "fiber-migrate-disabled"), []byte("#!/bin/sh\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "fiber-migrate-broken"), []byte("#!/bin/sh\nexit 1\n"), 0o700)) // #nosec G306 -- executable for the test
+
+	// a failing describe call is a warning, the other executables are loaded
+	warnings, err := migrations.LoadExternal(context.Background(), bin+string(os.PathListSeparator)+bin)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	at.ErrorContains(warnings[0], "fiber-migrate-broken")
+	all := migrations.All()
+	require.Len(t, all, len(migrations.Migrations)+1)
+	ext := all[len(all)-1]
+	at.Equal("fiber-migrate-rename", ext.Name(0))
+	at.Equal("Rename oldName to newName", ext.Description(0))
+
+	// registered migrations run before external ones and their queued
+	// transforms are applied before the executable sees the files
+	require.NoError(t, migrations.Register(migrations.Migration{
+		From: ">=2.0.0",
+		To:   "<4.0.0-0",
+		Functions: []migrations.MigrationFn{func(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
+			return internal.ChangeFileContent(cmd.Context(), cwd, func(content string) string {
+				return strings.ReplaceAll(content, "oldName()", "oldName(1)")
+			})
+		}},
+		Names: []string{"MigrateArgs"},
+	}))
+
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(main, []byte("package main\n\nfunc main() { oldName() }\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.go"), []byte("package main\n"), 0o600))
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	o := internal.NewOverlay()
+	r := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(internal.WithOverlay(context.Background(), o), r))
+
+	sel := migrations.Selection{Only: []string{"MigrateArgs", "fiber-migrate-rename"}}
+	require.NoError(t, migrations.DoMigration(cmd, dir, semver.MustParse("2.0.0"), semver.MustParse("3.0.0"), sel))
+	at.Contains(buf.String(), "Running fiber-migrate-rename")
+
+	b, err := o.ReadFile(main)
+	require.NoError(t, err)
+	at.Equal("package main\n\nfunc main() { newName(1) }\n", string(b))
+	at.Equal([]string{main}, o.Touched("fiber-migrate-rename"))
+	at.Equal([]string{main}, o.Changed())
+
+	require.Len(t, r.Findings(), 1)
+	at.Equal(internal.Finding{File: main, Migration: "fiber-migrate-rename", Message: "renamed oldName", Line: 3}, r.Findings()[0])
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+// MigrateGoWork updates the go.work file in root after the given modules were
+// migrated. Only the go directive is raised to the highest go version of the
+// migrated modules the workspace uses, its use entries are kept as they are.
+// It does nothing if root has no go.work file.
+func MigrateGoWork(cmd *cobra.Command, root string, modules []string) error {
+	workFile := filepath.Join(root, "go.work")
+	if _, err := os.Stat(workFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	if o := internal.OverlayFromContext(cmd.Context()); o != nil {
+		o.SetStage("MigrateGoWork")
+	}
+
+	b, err := internal.ReadFile(cmd.Context(), workFile)
+	if err != nil {
+		return err
+	}
+	wf, err := modfile.ParseWork(workFile, b, nil)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", workFile, err)
+	}
+
+	used := make(map[string]bool, len(wf.Use))
+	for _, u := range wf.Use {
+		used[filepath.Clean(filepath.Join(root, u.Path))] = true
+	}
+
+	var goVersion *semver.Version
+	for _, dir := range modules {
+		if !used[filepath.Clean(dir)] {
+			continue
+		}
+
+		modFile := filepath.Join(dir, "go.mod")
+		mb, err := internal.ReadFile(cmd.Context(), modFile)
+		if err != nil {
+			return err
+		}
+		mf, err := modfile.Parse(modFile, mb, nil)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", modFile, err)
+		}
+		if mf.Go == nil {
+			continue
+		}
+		v, err := semver.NewVersion(mf.Go.Version)
+		if err != nil {
+			return fmt.Errorf("parse go version in %s: %w", modFile, err)
+		}
+		if goVersion == nil || v.GreaterThan(goVersion) {
+			goVersion = v
+		}
+	}
+
+	if goVersion != nil {
+		curr, err := semver.NewVersion(strings.TrimSpace(goDirective(wf)))
+		if err != nil || curr.LessThan(goVersion) {
+			if err := wf.AddGoStmt(goVersion.Original()); err != nil {
+				return fmt.Errorf("set go version: %w", err)
+			}
+		}
+	}
+
+	wf.Cleanup()
+	out := modfile.Format(wf.Syntax)
+	if bytes.Equal(out, b) {
+		return nil
+	}
+	if err := internal.WriteFile(cmd.Context(), workFile, out); err != nil {
+		return err
+	}
+
+	cmd.Println("Migrating go.work")
+	return nil
+}
+
+func goDirective(wf *modfile.WorkFile) string {
+	if wf.Go == nil {
+		return ""
+	}
+	return wf.Go.Version
+}
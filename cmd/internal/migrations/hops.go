@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"fmt"
+
+	semver "github.com/Masterminds/semver/v3"
+)
+
+// Hop is a migration between two Fiber versions at most one major version
+// apart.
+type Hop struct {
+	From *semver.Version
+	To   *semver.Version
+}
+
+// String returns the hop as "v1.14.6 to v2.0.0".
+func (h Hop) String() string {
+	return fmt.Sprintf("v%s to v%s", h.From, h.To)
+}
+
+// Hops splits the migration from curr to target into one hop per major
+// version, e.g. v1.14.6 to v3.1.0 becomes v1.14.6 to v2.0.0 and v2.0.0 to
+// v3.1.0. The migrations of every hop see only its own versions, so the
+// v2 to v3 migrations never run against v1 code.
+func Hops(curr, target *semver.Version) []Hop {
+	var hops []Hop
+	from := curr
+	for major := curr.Major() + 1; major < target.Major(); major++ {
+		to := semver.New(major, 0, 0, "", "")
+		hops = append(hops, Hop{From: from, To: to})
+		from = to
+	}
+	return append(hops, Hop{From: from, To: target})
+}
@@ -0,0 +1,104 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal"
+	"github.com/gofiber/cli/cmd/internal/migrations"
+)
+
+func Test_Hops(t *testing.T) {
+	t.Parallel()
+
+	hops := func(curr, target string) []string {
+		var s []string
+		for _, h := range migrations.Hops(semver.MustParse(curr), semver.MustParse(target)) {
+			s = append(s, h.String())
+		}
+		return s
+	}
+
+	assert.Equal(t, []string{"v2.0.6 to v3.0.0"}, hops("2.0.6", "3.0.0"))
+	assert.Equal(t, []string{"v3.0.0 to v3.1.0"}, hops("3.0.0", "3.1.0"))
+	assert.Equal(t, []string{"v1.14.6 to v2.0.0", "v2.0.0 to v3.1.0"}, hops("1.14.6", "3.1.0"))
+	assert.Equal(t, []string{"v1.0.0 to v2.0.0", "v2.0.0 to v3.0.0", "v3.0.0 to v4.0.0"}, hops("1.0.0", "4.0.0"))
+}
+
+func Test_DoMigration_Hops(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber v1.14.6
+`
+	src := `package main
+
+import (
+	"github.com/gofiber/fiber"
+	"github.com/gofiber/fiber/middleware"
+)
+
+func handler(c *fiber.Ctx) {
+	c.Send("ok")
+}
+
+func main() {
+	app := fiber.New()
+	app.Use(middleware.Logger())
+	app.Get("/", handler)
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o600))
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	o := internal.NewOverlay()
+	r := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(internal.WithOverlay(context.Background(), o), r))
+	require.NoError(t, migrations.DoMigration(cmd, dir, semver.MustParse("1.14.6"), semver.MustParse("3.0.0"), migrations.Selection{}))
+	require.NoError(t, o.Commit())
+
+	out := buf.String()
+	assert.Contains(t, out, "Migrating from Fiber v1.14.6 to v2.0.0")
+	assert.Contains(t, out, "Migrating from Fiber v2.0.0 to v3.0.0")
+
+	content := readFile(t, filepath.Join(dir, "main.go"))
+	assert.Contains(t, content, `"github.com/gofiber/fiber/v3"`)
+	assert.Contains(t, content, `"github.com/gofiber/fiber/v3/middleware"`)
+	assert.Contains(t, content, "func handler(c fiber.Ctx)")
+	assert.Contains(t, readFile(t, filepath.Join(dir, "go.mod")), "require github.com/gofiber/fiber/v3 v3.0.0")
+
+	// the v2 to v3 migrations only ran in the second hop
+	var hops []string
+	for _, m := range r.Migrations() {
+		if m.Applied {
+			hops = append(hops, m.Hop)
+		}
+	}
+	assert.Equal(t, []string{"v1.14.6 to v2.0.0", "v2.0.0 to v3.0.0", "v2.0.0 to v3.0.0"}, hops)
+	assert.Equal(t, []string{"MigrateGoPkgs@v2", "MigrateGoPkgs@v3", "MigrateHandlerSignatures@v3"}, o.Stages()[:3])
+	assert.Equal(t, migrations.Describe("MigrateGoPkgs"), migrations.Describe("MigrateGoPkgs@v2"))
+}
+
+func Test_DoMigration_HopError(t *testing.T) {
+	t.Parallel()
+
+	// MigrateGoPkgs fails in the first hop without a go.mod file
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	cmd.SetContext(context.Background())
+	err := migrations.DoMigration(cmd, t.TempDir(), semver.MustParse("1.14.6"), semver.MustParse("3.0.0"), migrations.Selection{})
+	require.ErrorContains(t, err, "migration from Fiber v1.14.6 to v2.0.0 failed")
+}
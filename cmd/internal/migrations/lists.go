@@ -1,11 +1,18 @@
 package migrations
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	semver "github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
 
+	"github.com/gofiber/cli/cmd/internal"
 	v3migrations "github.com/gofiber/cli/cmd/internal/migrations/v3"
 )
 
@@ -14,9 +21,34 @@ type MigrationFn func(cmd *cobra.Command, cwd string, curr, target *semver.Versi
 
 // Migration is a single migration
 type Migration struct {
-	From      string
-	To        string
-	Functions []MigrationFn
+	// Descriptions optionally holds one-line summaries of the Functions
+	// keyed by name.
+	Descriptions map[string]string
+	From         string
+	To           string
+	Functions    []MigrationFn
+	// Names optionally names the Functions by index, FuncName is used for
+	// the others. Closures share the name of the function that created them
+	// and need a name to be told apart.
+	Names []string
+}
+
+// Name returns the name of the i-th function of the migration.
+func (m Migration) Name(i int) string {
+	if i < len(m.Names) && m.Names[i] != "" {
+		return m.Names[i]
+	}
+	return FuncName(m.Functions[i])
+}
+
+// Description returns the one-line summary of the i-th function of the
+// migration.
+func (m Migration) Description(i int) string {
+	name := m.Name(i)
+	if d, ok := m.Descriptions[name]; ok {
+		return d
+	}
+	return descriptions[name]
 }
 
 // Migrations is a list of all migrations
@@ -29,6 +61,7 @@ var Migrations = []Migration{
 		To:   "<4.0.0-0",
 		Functions: []MigrationFn{
 			v3migrations.MigrateHandlerSignatures,
+			v3migrations.MigrateCtxTypes,
 			v3migrations.MigrateViewBind,
 			v3migrations.MigrateParserMethods,
 			v3migrations.MigrateRedirectMethods,
@@ -46,6 +79,7 @@ var Migrations = []Migration{
 			v3migrations.MigrateCORSConfig,
 			v3migrations.MigrateCSRFConfig,
 			v3migrations.MigrateMonitorImport,
+			v3migrations.MigrateContribPackages,
 			v3migrations.MigrateHealthcheckConfig,
 			v3migrations.MigrateProxyTLSConfig,
 			v3migrations.MigrateAppTestConfig,
@@ -60,29 +94,233 @@ var Migrations = []Migration{
 	},
 }
 
-// DoMigration runs all migrations
-// It will run all migrations that match the current and target version
-func DoMigration(cmd *cobra.Command, cwd string, curr, target *semver.Version) error {
-	for _, m := range Migrations {
-		toC, err := semver.NewConstraint(m.To)
+// descriptions holds a one-line summary of every migration function, keyed by
+// the name returned by FuncName.
+var descriptions = map[string]string{
+	"MigrateGoPkgs":               "Rewrite Fiber import paths and the go.mod requirement to the target major version",
+	"MigrateHandlerSignatures":    "Change *fiber.Ctx parameters and results to the fiber.Ctx interface",
+	"MigrateCtxTypes":             "Change *fiber.Ctx in fields, maps, channels, type arguments and aliases to fiber.Ctx",
+	"MigrateViewBind":             "Replace the Ctx.Bind view binding helper with ViewBind",
+	"MigrateParserMethods":        "Replace BodyParser, QueryParser and friends with the Bind API",
+	"MigrateRedirectMethods":      "Replace RedirectBack, RedirectToRoute and Redirect with the Redirect API",
+	"MigrateGenericHelpers":       "Replace ParamsInt, QueryInt and similar helpers with generic functions",
+	"MigrateAddMethod":            "Pass the method of app.Add as a slice",
+	"MigrateMimeConstants":        "Rename deprecated MIME constants",
+	"MigrateLoggerTags":           "Rename deprecated logger tag constants",
+	"MigrateStaticRoutes":         "Replace app.Static with the static middleware",
+	"MigrateTrustedProxyConfig":   "Rename the trusted proxy options of fiber.Config",
+	"MigrateMount":                "Replace app.Mount with app.Use",
+	"MigrateConfigListenerFields": "Rename the Prefork and Network fields of fiber.Config",
+	"MigrateListenerCallbacks":    "Remove OnShutdown callbacks from ListenConfig",
+	"MigrateListenMethods":        "Replace ListenTLS and ListenMutualTLS with Listen and a ListenConfig",
+	"MigrateContextMethods":       "Rename Context, UserContext and SetUserContext methods",
+	"MigrateCORSConfig":           "Convert cors string options to slices",
+	"MigrateCSRFConfig":           "Rename and remove csrf configuration fields",
+	"MigrateMonitorImport":        "Move the monitor middleware to gofiber/contrib/v3 and require it",
+	"MigrateContribPackages":      "Move contrib, storage and template modules to their Fiber v3 releases",
+	"MigrateHealthcheckConfig":    "Rename healthcheck probe configuration fields",
+	"MigrateProxyTLSConfig":       "Replace proxy.WithTlsConfig with a client configuration",
+	"MigrateAppTestConfig":        "Pass a TestConfig to app.Test instead of a timeout",
+	"MigrateMiddlewareLocals":     "Replace middleware Locals lookups with helper functions",
+	"MigrateFilesystemMiddleware": "Replace the filesystem middleware with the static middleware",
+	"MigrateLimiterConfig":        "Rename limiter configuration fields",
+	"MigrateEnvVarConfig":         "Remove ExcludeVars from the envvar configuration",
+	"MigrateSessionConfig":        "Rename session configuration fields",
+	"MigrateReqHeaderParser":      "Replace ReqHeaderParser with the Bind API",
+	"MigrateGoVersion":            "Raise the go directive of go.mod files referencing Fiber",
+	"MigrateGoWork":               "Raise the go directive of go.work to match the migrated modules",
+}
+
+// Description returns the one-line summary of the migration function.
+func Description(fn MigrationFn) string {
+	return descriptions[FuncName(fn)]
+}
+
+// Describe returns the one-line summary of the migration function or step
+// with the given name. The major version suffix of steps of a multi-hop
+// migration, e.g. MigrateGoPkgs@v2, is ignored.
+func Describe(name string) string {
+	if i := strings.LastIndex(name, "@v"); i > 0 {
+		if _, err := strconv.ParseUint(name[i+2:], 10, 64); err == nil {
+			name = name[:i]
+		}
+	}
+	for _, m := range All() {
+		for i := range m.Functions {
+			if m.Name(i) == name {
+				return m.Description(i)
+			}
+		}
+	}
+	return descriptions[name]
+}
+
+// Selection restricts the migration functions that are run. An empty Only
+// selects every function, Skip removes functions from the selection. Both
+// contain names as returned by FuncName.
+type Selection struct {
+	Only []string
+	Skip []string
+}
+
+// Includes reports whether the migration function with the given name is
+// selected.
+func (s Selection) Includes(name string) bool {
+	if len(s.Only) > 0 && !slices.Contains(s.Only, name) {
+		return false
+	}
+	return !slices.Contains(s.Skip, name)
+}
+
+// Validate returns an error if the selection names an unknown migration.
+func (s Selection) Validate() error {
+	known := make(map[string]bool)
+	for _, m := range All() {
+		for i := range m.Functions {
+			known[m.Name(i)] = true
+		}
+	}
+	for _, name := range append(append([]string(nil), s.Only...), s.Skip...) {
+		if !known[name] {
+			return fmt.Errorf("unknown migration %q", name)
+		}
+	}
+	return nil
+}
+
+// Applicable returns the migrations whose version constraints match the
+// current and target version.
+func Applicable(curr, target *semver.Version) ([]Migration, error) {
+	var applicable []Migration
+	for _, m := range All() {
+		ok, err := m.matches(curr, target)
 		if err != nil {
-			return fmt.Errorf("parse to constraint %s: %w", m.To, err)
+			return nil, err
 		}
-		fromC, err := semver.NewConstraint(m.From)
+		if ok {
+			applicable = append(applicable, m)
+		}
+	}
+	return applicable, nil
+}
+
+func (m Migration) matches(curr, target *semver.Version) (bool, error) {
+	fromC, toC, err := m.constraints()
+	if err != nil {
+		return false, err
+	}
+	return fromC.Check(curr) && toC.Check(target), nil
+}
+
+func (m Migration) constraints() (from, to *semver.Constraints, err error) {
+	to, err = semver.NewConstraint(m.To)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse to constraint %s: %w", m.To, err)
+	}
+	from, err = semver.NewConstraint(m.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse from constraint %s: %w", m.From, err)
+	}
+	return from, to, nil
+}
+
+// DoMigration runs all migrations
+// It will run all migrations that match the current and target version and
+// are included in the selection. Migrations across several major versions
+// run hop by hop, see Hops, and the error names the hop that failed.
+func DoMigration(cmd *cobra.Command, cwd string, curr, target *semver.Version, sel Selection) error {
+	if err := sel.Validate(); err != nil {
+		return err
+	}
+
+	hops := Hops(curr, target)
+	if len(hops) == 1 {
+		return doHop(cmd, cwd, hops[0], sel, false)
+	}
+	for _, h := range hops {
+		cmd.Printf("Migrating from Fiber %s\n", h)
+		if err := doHop(cmd, cwd, h, sel, true); err != nil {
+			return fmt.Errorf("migration from Fiber %s failed: %w", h, err)
+		}
+	}
+	return nil
+}
+
+// doHop runs the migrations of a single hop. If the migration has several
+// hops, the steps are named after the major version they migrate to, e.g.
+// MigrateGoPkgs@v2, to keep the changes of the hops apart.
+func doHop(cmd *cobra.Command, cwd string, h Hop, sel Selection, multi bool) error {
+	r := internal.ReportFromContext(cmd.Context())
+
+	// the Go files are loaded once and all file transforms of the applicable
+	// migrations are applied in a single pass after they were collected
+	ctx := cmd.Context()
+	p := internal.NewPipeline(cwd, 0)
+	cmd.SetContext(internal.WithPipeline(ctx, p))
+	defer cmd.SetContext(ctx)
+
+	for _, m := range All() {
+		ok, err := m.matches(h.From, h.To)
 		if err != nil {
-			return fmt.Errorf("parse from constraint %s: %w", m.From, err)
+			return err
+		}
+		result := internal.MigrationResult{Module: cwd, From: m.From, To: m.To, Applied: ok}
+		if multi {
+			result.Hop = h.String()
+		}
+		if !ok {
+			cmd.Printf("Skipping migration from %s to %s\n", m.From, m.To)
+			if r != nil {
+				r.AddMigration(result)
+			}
+			continue
 		}
 
-		if fromC.Check(curr) && toC.Check(target) {
-			for _, fn := range m.Functions {
-				if err := fn(cmd, cwd, curr, target); err != nil {
-					return err
+		for i, fn := range m.Functions {
+			name := m.Name(i)
+			if !sel.Includes(name) {
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+			stage := name
+			if multi {
+				stage = fmt.Sprintf("%s@v%d", name, h.To.Major())
+			}
+			if o := internal.OverlayFromContext(cmd.Context()); o != nil {
+				o.SetStage(stage)
+			}
+			if r != nil {
+				r.SetStage(stage)
+			}
+			p.SetStage(stage)
+			if err := fn(cmd, cwd, h.From, h.To); err != nil {
+				// apply the transforms of the previous steps, so that
+				// partial changes can be kept
+				if perr := p.Run(cmd.Context()); perr != nil {
+					return errors.Join(err, perr)
 				}
+				return err
 			}
-		} else {
-			cmd.Printf("Skipping migration from %s to %s\n", m.From, m.To)
+			result.Functions = append(result.Functions, stage)
+		}
+		if r != nil {
+			r.AddMigration(result)
 		}
 	}
 
-	return nil
+	return p.Run(cmd.Context())
+}
+
+// FuncName returns the name of the migration function without its package,
+// e.g. "MigrateCORSConfig". Closures report the name of the function that
+// created them.
+func FuncName(fn MigrationFn) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+
+	parts := strings.Split(name, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	return parts[0]
 }
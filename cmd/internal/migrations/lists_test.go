@@ -0,0 +1,85 @@
+package migrations_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal/migrations"
+)
+
+func Test_Migrations_Descriptions(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range migrations.Migrations {
+		for _, fn := range m.Functions {
+			assert.NotEmpty(t, migrations.Description(fn), migrations.FuncName(fn))
+		}
+	}
+}
+
+func Test_Selection(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	all := migrations.Selection{}
+	at.True(all.Includes("MigrateCORSConfig"))
+	at.NoError(all.Validate())
+
+	only := migrations.Selection{Only: []string{"MigrateCORSConfig"}, Skip: []string{"MigrateCORSConfig"}}
+	at.False(only.Includes("MigrateCORSConfig"))
+	at.False(only.Includes("MigrateCSRFConfig"))
+
+	skip := migrations.Selection{Skip: []string{"MigrateStaticRoutes"}}
+	at.False(skip.Includes("MigrateStaticRoutes"))
+	at.True(skip.Includes("MigrateMount"))
+
+	err := migrations.Selection{Only: []string{"MigrateNothing"}}.Validate()
+	require.Error(t, err)
+	at.Contains(err.Error(), "MigrateNothing")
+}
+
+func Test_Applicable(t *testing.T) {
+	t.Parallel()
+
+	applicable, err := migrations.Applicable(semver.MustParse("2.0.0"), semver.MustParse("3.0.0"))
+	require.NoError(t, err)
+	require.Len(t, applicable, 2)
+
+	applicable, err = migrations.Applicable(semver.MustParse("1.0.0"), semver.MustParse("2.0.0"))
+	require.NoError(t, err)
+	require.Len(t, applicable, 1)
+}
+
+func Test_DoMigration_Selection(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func main() {
+	app := fiber.New()
+	app.Mount("/api", app)
+	app.Static("/", "./public")
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o600))
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	sel := migrations.Selection{Only: []string{"MigrateMount", "MigrateStaticRoutes"}, Skip: []string{"MigrateStaticRoutes"}}
+	require.NoError(t, migrations.DoMigration(cmd, dir, semver.MustParse("2.0.0"), semver.MustParse("3.0.0"), sel))
+
+	content := readFile(t, filepath.Join(dir, "main.go"))
+	assert.Contains(t, content, "github.com/gofiber/fiber/v2")
+	assert.Contains(t, content, `app.Use("/api", app)`)
+	assert.Contains(t, content, `app.Static("/", "./public")`)
+}
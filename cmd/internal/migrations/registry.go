@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	registered     []Migration
+	ruleMigrations []Migration
+	external       []Migration
+	registryMu     sync.Mutex
+)
+
+// Register adds a migration that runs after the built-in migrations. It is
+// meant to be called from init functions of programs that embed the fiber
+// command. Every function needs a name that is not used by another
+// migration yet.
+func Register(m Migration) error {
+	if len(m.Functions) == 0 {
+		return errors.New("migration has no functions")
+	}
+	if _, _, err := m.constraints(); err != nil {
+		return err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if err := checkNames(append(all(), m)); err != nil {
+		return err
+	}
+	registered = append(registered, m)
+	return nil
+}
+
+// All returns the built-in migrations followed by the registered migrations,
+// the rules and the external migrations.
+func All() []Migration {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	return all()
+}
+
+func all() []Migration {
+	ms := make([]Migration, 0, len(Migrations)+len(registered)+len(ruleMigrations)+len(external))
+	ms = append(ms, Migrations...)
+	ms = append(ms, registered...)
+	ms = append(ms, ruleMigrations...)
+	return append(ms, external...)
+}
+
+func checkNames(ms []Migration) error {
+	seen := make(map[string]bool)
+	for _, m := range ms {
+		for i := range m.Functions {
+			name := m.Name(i)
+			if seen[name] {
+				return fmt.Errorf("migration %q is already registered", name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package migrations_test
+
+import (
+	"testing"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal/migrations"
+)
+
+func noopMigration() migrations.MigrationFn {
+	return func(_ *cobra.Command, _ string, _, _ *semver.Version) error { return nil }
+}
+
+func Test_Register(t *testing.T) {
+	t.Cleanup(migrations.ResetRegistered)
+
+	at := assert.New(t)
+
+	require.ErrorContains(t, migrations.Register(migrations.Migration{From: ">=2.0.0", To: ">=3.0.0"}), "no functions")
+	require.ErrorContains(t, migrations.Register(migrations.Migration{
+		From:      "two",
+		To:        ">=3.0.0",
+		Functions: []migrations.MigrationFn{noopMigration()},
+	}), "parse from constraint")
+	require.ErrorContains(t, migrations.Register(migrations.Migration{
+		From:      ">=2.0.0",
+		To:        ">=3.0.0",
+		Functions: []migrations.MigrationFn{noopMigration()},
+		Names:     []string{"MigrateMount"},
+	}), `migration "MigrateMount" is already registered`)
+	// closures of the same function need names
+	require.ErrorContains(t, migrations.Register(migrations.Migration{
+		From:      ">=2.0.0",
+		To:        ">=3.0.0",
+		Functions: []migrations.MigrationFn{noopMigration(), noopMigration()},
+	}), `migration "noopMigration" is already registered`)
+
+	require.NoError(t, migrations.Register(migrations.Migration{
+		From:         ">=2.0.0",
+		To:           ">=3.0.0",
+		Functions:    []migrations.MigrationFn{noopMigration(), noopMigration()},
+		Names:        []string{"MigrateTeamLogger", "MigrateTeamAuth"},
+		Descriptions: map[string]string{"MigrateTeamLogger": "Migrate the team logger"},
+	}))
+
+	all := migrations.All()
+	m := all[len(all)-1]
+	at.Equal("MigrateTeamAuth", m.Name(1))
+	at.Equal("Migrate the team logger", m.Description(0))
+	at.Equal("Replace app.Mount with app.Use", all[1].Description(11))
+
+	require.NoError(t, migrations.Selection{Only: []string{"MigrateTeamAuth"}}.Validate())
+
+	applicable, err := migrations.Applicable(semver.MustParse("2.0.0"), semver.MustParse("3.0.0"))
+	require.NoError(t, err)
+	at.Len(applicable, 3)
+}
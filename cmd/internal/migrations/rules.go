@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"fmt"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/gofiber/cli/cmd/internal"
+	"github.com/gofiber/cli/cmd/internal/migrations/rules"
+)
+
+// LoadRules loads the rules files and makes every rule available as a
+// migration named after the rule. Rules run after the registered migrations
+// and before the external ones. They replace the rules of a previous call,
+// no files remove them.
+func LoadRules(files ...string) error {
+	var ms []Migration
+	for _, file := range files {
+		rs, err := rules.Load(file)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		for _, r := range rs {
+			ms = append(ms, Migration{
+				From:         r.From,
+				To:           r.To,
+				Functions:    []MigrationFn{ruleMigration(r)},
+				Names:        []string{r.Name},
+				Descriptions: map[string]string{r.Name: r.Description},
+			})
+		}
+	}
+	for _, m := range ms {
+		if _, _, err := m.constraints(); err != nil {
+			return fmt.Errorf("rule %s: %w", m.Names[0], err)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	prev := ruleMigrations
+	ruleMigrations = ms
+	if err := checkNames(all()); err != nil {
+		ruleMigrations = prev
+		return err
+	}
+	return nil
+}
+
+// ruleMigration applies the rule to the Go files of the module.
+func ruleMigration(r rules.Rule) MigrationFn {
+	return func(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
+		for _, processor := range r.Processors() {
+			if err := internal.ChangeFileAST(cmd.Context(), cwd, processor); err != nil {
+				return fmt.Errorf("failed to apply rule %s: %w", r.Name, err)
+			}
+		}
+
+		cmd.Printf("Applying rule %s\n", r.Name)
+		return nil
+	}
+}
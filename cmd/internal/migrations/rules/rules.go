@@ -0,0 +1,269 @@
+// Package rules implements declarative migration rules loaded from YAML files.
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+// File is the content of a rules file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule rewrites the uses of a package in files that import it. From and To
+// are constraints on the current and target Fiber version like those of the
+// built-in migrations.
+type Rule struct {
+	// Rename renames pkg.Old references, e.g. functions, types and constants.
+	Rename map[string]string `yaml:"rename"`
+	// Fields holds the field changes of composite literals by type name.
+	Fields map[string]FieldRule `yaml:"fields"`
+	// Name identifies the rule for --only and --skip.
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Import is the import path of the package, path.Match patterns such as
+	// github.com/gofiber/fiber/*/middleware/logger are allowed.
+	Import  string    `yaml:"import"`
+	From    string    `yaml:"from"`
+	To      string    `yaml:"to"`
+	Replace []Replace `yaml:"replace"`
+}
+
+// FieldRule renames and removes keyed fields of a composite literal.
+type FieldRule struct {
+	Rename map[string]string `yaml:"rename"`
+	// Remove maps the removed fields to the manual follow-up.
+	Remove map[string]string `yaml:"remove"`
+}
+
+// Replace replaces text after the package clause of files importing the
+// package. Old is a regular expression if Regexp is set and New may refer to
+// its submatches as $1.
+type Replace struct {
+	Old    string `yaml:"old"`
+	New    string `yaml:"new"`
+	Regexp bool   `yaml:"regexp"`
+}
+
+// Load reads and validates the rules file. Rules without a name are named
+// after the file and their position, e.g. rules.yaml#2.
+func Load(filename string) ([]Rule, error) {
+	b, err := os.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return nil, fmt.Errorf("read rules: %w", err)
+	}
+	rules, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i := range rules {
+		if rules[i].Name == "" {
+			rules[i].Name = fmt.Sprintf("%s#%d", filepath.Base(filename), i+1)
+		}
+	}
+	return rules, nil
+}
+
+// Parse decodes and validates the rules of a rules file. Unknown keys are an
+// error.
+func Parse(b []byte) ([]Rule, error) {
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+
+	for i, r := range f.Rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if r.From == "" {
+			f.Rules[i].From = ">=0.0.0-0"
+		}
+		if r.To == "" {
+			f.Rules[i].To = ">=0.0.0-0"
+		}
+	}
+	return f.Rules, nil
+}
+
+func (r Rule) validate() error {
+	if r.Import == "" {
+		return errors.New("import is required")
+	}
+	if _, err := path.Match(r.Import, ""); err != nil {
+		return fmt.Errorf("invalid import pattern %q: %w", r.Import, err)
+	}
+	if len(r.Rename) == 0 && len(r.Fields) == 0 && len(r.Replace) == 0 {
+		return errors.New("rule has no rename, fields or replace")
+	}
+
+	for from, to := range r.Rename {
+		if !token.IsIdentifier(from) || !token.IsIdentifier(to) {
+			return fmt.Errorf("invalid rename %q to %q", from, to)
+		}
+	}
+	for typeName, fr := range r.Fields {
+		if !token.IsIdentifier(typeName) {
+			return fmt.Errorf("invalid type name %q", typeName)
+		}
+		for from, to := range fr.Rename {
+			if !token.IsIdentifier(from) || !token.IsIdentifier(to) {
+				return fmt.Errorf("invalid field rename %q to %q", from, to)
+			}
+		}
+		for field := range fr.Remove {
+			if !token.IsIdentifier(field) {
+				return fmt.Errorf("invalid field %q", field)
+			}
+		}
+	}
+	for _, rep := range r.Replace {
+		if rep.Old == "" {
+			return errors.New("replace needs old")
+		}
+		if rep.Regexp {
+			if _, err := regexp.Compile(rep.Old); err != nil {
+				return fmt.Errorf("invalid replace pattern: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Processors returns the transforms of the rule. Fields are changed first,
+// then references are renamed and finally the replacements run one after
+// another, each on the text the previous one produced.
+func (r Rule) Processors() []internal.ASTProcessor {
+	var processors []internal.ASTProcessor
+	if len(r.Fields) > 0 {
+		processors = append(processors, r.changeFields)
+	}
+	if len(r.Rename) > 0 {
+		processors = append(processors, r.rename)
+	}
+	if len(r.Replace) > 0 {
+		processors = append(processors, r.replace)
+	}
+	return processors
+}
+
+// pkgName returns the name the package of the rule is imported as.
+func (r Rule) pkgName(f *internal.GoFile) (string, bool) {
+	spec := f.FindImport(func(p string) bool {
+		ok, _ := path.Match(r.Import, p) //nolint:errcheck // validated
+		return ok
+	})
+	if spec == nil {
+		return "", false
+	}
+	name := internal.ImportName(spec)
+	if name == "_" || name == "." {
+		return "", false
+	}
+	return name, true
+}
+
+func (r Rule) changeFields(f *internal.GoFile) {
+	pkg, ok := r.pkgName(f)
+	if !ok {
+		return
+	}
+
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if sel, ok := lit.Type.(*ast.SelectorExpr); ok && isPkg(sel.X, pkg) {
+			if fr, ok := r.Fields[sel.Sel.Name]; ok {
+				fr.apply(f, lit)
+			}
+		}
+		return true
+	})
+}
+
+func (r Rule) rename(f *internal.GoFile) {
+	pkg, ok := r.pkgName(f)
+	if !ok {
+		return
+	}
+
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if to, ok := r.Rename[sel.Sel.Name]; ok && isPkg(sel.X, pkg) {
+				f.Replace(sel.Sel, to)
+			}
+		}
+		return true
+	})
+}
+
+func isPkg(expr ast.Expr, pkg string) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == pkg
+}
+
+func (fr FieldRule) apply(f *internal.GoFile, lit *ast.CompositeLit) {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if action, ok := fr.Remove[key.Name]; ok {
+			if action == "" {
+				action = "Check whether the value is still needed"
+			}
+			f.Delete(kv)
+			f.Report(kv, fmt.Sprintf("removed %s.%s", f.Source(lit.Type), key.Name), action)
+			continue
+		}
+		if to, ok := fr.Rename[key.Name]; ok {
+			f.Replace(kv.Key, to)
+		}
+	}
+}
+
+// replace applies the replacements to the text after the package clause.
+// They run on a string in order instead of recording edits against the
+// parsed source, so a replacement sees the result of the previous ones.
+func (r Rule) replace(f *internal.GoFile) {
+	if _, ok := r.pkgName(f); !ok {
+		return
+	}
+
+	tf := f.Fset.File(f.File.Package)
+	start, end := f.File.Name.End(), token.Pos(tf.Base()+tf.Size())
+	orig := f.SourceRange(start, end)
+
+	src := orig
+	for _, rep := range r.Replace {
+		if rep.Regexp {
+			src = regexp.MustCompile(rep.Old).ReplaceAllString(src, rep.New)
+		} else {
+			src = strings.ReplaceAll(src, rep.Old, rep.New)
+		}
+	}
+	if src != orig {
+		f.ReplaceRange(start, end, src)
+	}
+}
@@ -0,0 +1,195 @@
+package rules_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal"
+	"github.com/gofiber/cli/cmd/internal/migrations/rules"
+)
+
+const rulesYAML = `rules:
+  - name: MigrateAcmeLog
+    description: Rename the acme logger API
+    import: github.com/acme/fiberlog
+    from: ">=2.0.0"
+    to: "<4.0.0-0"
+    rename:
+      NewLogger: New
+    fields:
+      Config:
+        rename:
+          Out: Output
+        remove:
+          Color: Configure colors with a Theme
+    replace:
+      - old: fiberlog.Level(
+        new: fiberlog.SetLevel(
+      - old: '"level:(\w+)"'
+        new: '"$1"'
+        regexp: true
+  - import: github.com/acme/other
+    rename:
+      A: B
+`
+
+func apply(t *testing.T, rs []rules.Rule, src string) (string, []internal.Finding) {
+	t.Helper()
+
+	var findings []internal.Finding
+	for _, r := range rs {
+		for _, processor := range r.Processors() {
+			f, err := internal.ParseGoFile("main.go", []byte(src))
+			require.NoError(t, err)
+			processor(f)
+			out, err := f.Apply()
+			require.NoError(t, err)
+			findings = append(findings, f.Findings()...)
+			src = string(out)
+		}
+	}
+	return src, findings
+}
+
+func Test_Load(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(rulesYAML), 0o600))
+
+	rs, err := rules.Load(file)
+	require.NoError(t, err)
+	require.Len(t, rs, 2)
+	at.Equal("MigrateAcmeLog", rs[0].Name)
+	at.Equal(">=2.0.0", rs[0].From)
+	at.Equal("rules.yaml#2", rs[1].Name)
+	at.Equal(">=0.0.0-0", rs[1].From)
+	at.Equal(">=0.0.0-0", rs[1].To)
+
+	_, err = rules.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.ErrorContains(t, err, "read rules")
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"rules:\n  - rename: {A: B}\n":                                       "import is required",
+		"rules:\n  - import: x\n":                                            "rule has no rename, fields or replace",
+		"rules:\n  - import: x\n    rename: {A: B.C}\n":                      "invalid rename",
+		"rules:\n  - import: x\n    fields: {Config: {remove: {a-b: x}}}\n":  "invalid field",
+		"rules:\n  - import: x\n    replace: [{old: '(', regexp: true}]\n":   "invalid replace pattern",
+		"rules:\n  - import: x\n    renames: {A: B}\n":                       "field renames not found",
+		"rules:\n  - import: '['\n    rename: {A: B}\n":                      "invalid import pattern",
+		"rules:\n  - import: x\n    replace: [{new: y}]\n":                   "replace needs old",
+		"rules:\n  - import: x\n    fields: {Config: {rename: {A: \"\"}}}\n": "invalid field rename",
+	}
+	for in, want := range cases {
+		_, err := rules.Parse([]byte(in))
+		require.ErrorContains(t, err, want, in)
+	}
+}
+
+func Test_Rule_Processors(t *testing.T) {
+	t.Parallel()
+
+	rs, err := rules.Parse([]byte(rulesYAML))
+	require.NoError(t, err)
+
+	src := `package main
+
+import log "github.com/acme/fiberlog"
+
+func main() {
+	l := log.NewLogger(log.Config{
+		Out:   os.Stdout,
+		Color: log.NewLogger(log.Config{}),
+	})
+	log.Level("level:debug")
+	other.NewLogger()
+}
+`
+	out, findings := apply(t, rs, src)
+	// the replacements are literal text, the alias is not resolved, and the
+	// alignment of renamed fields is kept
+	assert.Equal(t, `package main
+
+import log "github.com/acme/fiberlog"
+
+func main() {
+	l := log.New(log.Config{
+		Output:   os.Stdout,
+	})
+	log.Level("debug")
+	other.NewLogger()
+}
+`, out)
+	require.Len(t, findings, 1)
+	assert.Equal(t, internal.Finding{File: "main.go", Line: 8, Message: "removed log.Config.Color", Action: "Configure colors with a Theme"}, findings[0])
+
+	// files not importing the package are left alone
+	src = "package main\n\nfunc main() { fiberlog.Level(\"level:debug\") }\n"
+	out, findings = apply(t, rs, src)
+	assert.Equal(t, src, out)
+	assert.Empty(t, findings)
+}
+
+func Test_Rule_Chained(t *testing.T) {
+	t.Parallel()
+
+	rs, err := rules.Parse([]byte(`rules:
+  - import: github.com/acme/fiberlog
+    rename:
+      Level: SetLevel
+    replace:
+      - old: fiberlog.SetLevel(
+        new: fiberlog.Configure().Level(
+      - old: 'Level\("(\w+)"\)'
+        new: 'Level(fiberlog.$1)'
+        regexp: true
+  - import: github.com/acme/fiberlog
+    replace:
+      - old: fiberlog.Configure()
+        new: fiberlog.Default()
+`))
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(file, []byte(`package main
+
+import "github.com/acme/fiberlog"
+
+func main() {
+	fiberlog.Level("debug")
+}
+`), 0o600))
+
+	// every transform of both rules sees the result of the previous ones
+	p := internal.NewPipeline(dir, 1)
+	ctx := internal.WithPipeline(context.Background(), p)
+	for _, r := range rs {
+		for _, processor := range r.Processors() {
+			require.NoError(t, internal.ChangeFileAST(ctx, dir, processor))
+		}
+	}
+	require.NoError(t, p.Run(ctx))
+
+	b, err := os.ReadFile(file) // #nosec G304 -- test file
+	require.NoError(t, err)
+	assert.Equal(t, `package main
+
+import "github.com/acme/fiberlog"
+
+func main() {
+	fiberlog.Default().Level(fiberlog.debug)
+}
+`, string(b))
+}
@@ -1,8 +1,10 @@
 package v3
 
 import (
+	"bytes"
 	"fmt"
-	"regexp"
+	"go/ast"
+	"go/token"
 	"strconv"
 	"strings"
 
@@ -12,11 +14,44 @@ import (
 	"github.com/gofiber/cli/cmd/internal"
 )
 
+// MigrateHandlerSignatures changes *fiber.Ctx parameters and results of
+// function signatures to the fiber.Ctx interface. Dereferences of the changed
+// parameters are reported, MigrateCtxTypes can no longer find them.
 func MigrateHandlerSignatures(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	sigReplacer := strings.NewReplacer("*fiber.Ctx", "fiber.Ctx")
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return sigReplacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		params := make(map[*ast.Object]bool)
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			ft, ok := n.(*ast.FuncType)
+			if !ok {
+				return true
+			}
+			for _, list := range []*ast.FieldList{ft.Params, ft.Results} {
+				if list == nil {
+					continue
+				}
+				for _, field := range list.List {
+					if star, ok := field.Type.(*ast.StarExpr); ok && isCtxType(star.X, fiberName) {
+						f.ReplaceRange(star.Star, star.X.Pos(), "")
+						for _, id := range field.Names {
+							if id.Obj != nil {
+								params[id.Obj] = true
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			if star, ok := n.(*ast.StarExpr); ok {
+				reportCtxDeref(f, star, params)
+			}
+			return true
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate handler signatures: %w", err)
@@ -27,17 +62,144 @@ func MigrateHandlerSignatures(cmd *cobra.Command, cwd string, _, _ *semver.Versi
 	return nil
 }
 
+// MigrateCtxTypes changes every other use of *fiber.Ctx as a type, e.g. in
+// struct fields, interface methods, maps, channels, type arguments and
+// aliases, to the fiber.Ctx interface. Uses without an interface equivalent,
+// such as fiber.Ctx literals and dereferenced pointers, are reported.
+func MigrateCtxTypes(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		c := &ctxTypes{f: f, fiberName: fiberName}
+		c.collect()
+		ast.Inspect(f.File, c.visit)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate Ctx types: %w", err)
+	}
+
+	cmd.Println("Migrating Ctx types")
+
+	return nil
+}
+
+// ctxTypes rewrites the *fiber.Ctx types of a file. Types declared in the
+// file as fiber.Ctx or an alias of it are treated like fiber.Ctx, as an
+// interface they keep its methods.
+type ctxTypes struct {
+	f         *internal.GoFile
+	types     map[*ast.Object]bool
+	pointers  map[*ast.Object]bool
+	fiberName string
+}
+
+// isCtx reports whether expr is fiber.Ctx or a type declared as it.
+func (c *ctxTypes) isCtx(expr ast.Expr) bool {
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Obj != nil && c.types[id.Obj]
+	}
+	return isSelector(expr, c.fiberName, "Ctx")
+}
+
+// collect finds the types declared as fiber.Ctx and the variables,
+// parameters and fields declared as *fiber.Ctx.
+func (c *ctxTypes) collect() {
+	c.types = make(map[*ast.Object]bool)
+	c.pointers = make(map[*ast.Object]bool)
+
+	ast.Inspect(c.f.File, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && c.isCtx(spec.Type) && spec.Name.Obj != nil {
+			c.types[spec.Name.Obj] = true
+		}
+		return true
+	})
+
+	ast.Inspect(c.f.File, func(n ast.Node) bool {
+		var names []*ast.Ident
+		var typ ast.Expr
+		switch n := n.(type) {
+		case *ast.Field:
+			names, typ = n.Names, n.Type
+		case *ast.ValueSpec:
+			names, typ = n.Names, n.Type
+		case *ast.AssignStmt:
+			// c, ok := v.(*fiber.Ctx)
+			if n.Tok != token.DEFINE || len(n.Rhs) != 1 {
+				return true
+			}
+			assert, ok := n.Rhs[0].(*ast.TypeAssertExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := n.Lhs[0].(*ast.Ident); ok {
+				names, typ = []*ast.Ident{id}, assert.Type
+			}
+		default:
+			return true
+		}
+		if star, ok := typ.(*ast.StarExpr); ok && c.isCtx(star.X) {
+			for _, id := range names {
+				if id.Obj != nil {
+					c.pointers[id.Obj] = true
+				}
+			}
+		}
+		return true
+	})
+}
+
+// ctxAction is the follow-up for uses of *fiber.Ctx that have no equivalent.
+const ctxAction = "fiber.Ctx is an interface in v3, use the context passed to the handler"
+
+// reportCtxDeref reports star if it dereferences one of the *fiber.Ctx
+// variables in pointers.
+func reportCtxDeref(f *internal.GoFile, star *ast.StarExpr, pointers map[*ast.Object]bool) {
+	if id, ok := star.X.(*ast.Ident); ok && id.Obj != nil && pointers[id.Obj] {
+		f.Report(star, fmt.Sprintf("dereferenced *fiber.Ctx %s", id.Name), "Use "+id.Name+" directly, "+ctxAction)
+	}
+}
+
+func (c *ctxTypes) visit(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.StarExpr:
+		if c.isCtx(n.X) {
+			c.f.ReplaceRange(n.Star, n.X.Pos(), "")
+			return false
+		}
+		reportCtxDeref(c.f, n, c.pointers)
+	case *ast.CompositeLit:
+		if c.isCtx(n.Type) {
+			c.f.Report(n, "fiber.Ctx literal", "Remove the literal, "+ctxAction)
+		}
+	case *ast.CallExpr:
+		if id, ok := n.Fun.(*ast.Ident); ok && id.Name == "new" && id.Obj == nil && len(n.Args) == 1 && c.isCtx(n.Args[0]) {
+			c.f.Report(n, "new(fiber.Ctx)", "Remove the allocation, "+ctxAction)
+		}
+	}
+	return true
+}
+
+// renameCtxMethods renames methods called on fiber.Ctx values. A replacement
+// may contain a call chain, e.g. "Bind().Body".
+func renameCtxMethods(f *internal.GoFile, renames map[string]string) {
+	forEachCtxCall(f, func(_ *ast.CallExpr, sel *ast.SelectorExpr) {
+		if to, ok := renames[sel.Sel.Name]; ok {
+			f.Replace(sel.Sel, to)
+		}
+	})
+}
+
 // MigrateParserMethods replaces deprecated parser helper methods with the new binding API
 func MigrateParserMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		".BodyParser(", ".Bind().Body(",
-		".CookieParser(", ".Bind().Cookie(",
-		".ParamsParser(", ".Bind().URI(",
-		".QueryParser(", ".Bind().Query(",
-	)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		renameCtxMethods(f, map[string]string{
+			"BodyParser":   "Bind().Body",
+			"CookieParser": "Bind().Cookie",
+			"ParamsParser": "Bind().URI",
+			"QueryParser":  "Bind().Query",
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate parser methods: %w", err)
@@ -49,15 +211,20 @@ func MigrateParserMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateRedirectMethods updates redirect helper methods to the new API
 func MigrateRedirectMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		".RedirectBack(", ".Redirect().Back(",
-		".RedirectToRoute(", ".Redirect().Route(",
-	)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		re := regexp.MustCompile(`\.Redirect\(`)
-		content = re.ReplaceAllString(content, ".Redirect().To(")
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		forEachCtxCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			switch sel.Sel.Name {
+			case "Redirect":
+				// Redirect() without arguments is already the v3 API
+				if len(call.Args) > 0 {
+					f.Replace(sel.Sel, "Redirect().To")
+				}
+			case "RedirectBack":
+				f.Replace(sel.Sel, "Redirect().Back")
+			case "RedirectToRoute":
+				f.Replace(sel.Sel, "Redirect().Route")
+			}
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate redirect methods: %w", err)
@@ -69,17 +236,30 @@ func MigrateRedirectMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version
 
 // MigrateGenericHelpers migrates helper functions that now use generics
 func MigrateGenericHelpers(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	reParamsInt := regexp.MustCompile(`(\w+)\.ParamsInt\(`)
-	reQueryInt := regexp.MustCompile(`(\w+)\.QueryInt\(`)
-	reQueryFloat := regexp.MustCompile(`(\w+)\.QueryFloat\(`)
-	reQueryBool := regexp.MustCompile(`(\w+)\.QueryBool\(`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = reParamsInt.ReplaceAllString(content, "fiber.Params[int]($1, ")
-		content = reQueryInt.ReplaceAllString(content, "fiber.Query[int]($1, ")
-		content = reQueryFloat.ReplaceAllString(content, "fiber.Query[float64]($1, ")
-		content = reQueryBool.ReplaceAllString(content, "fiber.Query[bool]($1, ")
-
-		return content
+	helpers := map[string]string{
+		"ParamsInt":  "Params[int]",
+		"QueryInt":   "Query[int]",
+		"QueryFloat": "Query[float64]",
+		"QueryBool":  "Query[bool]",
+	}
+
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachCtxCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			helper, ok := helpers[sel.Sel.Name]
+			if !ok {
+				return
+			}
+			prefix := fmt.Sprintf("%s.%s(%s", fiberName, helper, f.Source(sel.X))
+			if len(call.Args) == 0 {
+				f.ReplaceRange(call.Pos(), call.Rparen, prefix)
+				return
+			}
+			f.ReplaceRange(call.Pos(), call.Args[0].Pos(), prefix+", ")
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate generic helpers: %w", err)
@@ -89,16 +269,23 @@ func MigrateGenericHelpers(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 	return nil
 }
 
-// MigrateContextMethods updates context related methods to the new names
+// MigrateContextMethods updates context related methods to the new names:
+// Context becomes RequestCtx, while UserContext and SetUserContext become
+// Context and SetContext, which hold the context.Context of the request in v3.
 func MigrateContextMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		".Context()", ".RequestCtx()",
-		".UserContext()", ".Context()",
-		".SetUserContext(", ".SetContext(", // TODO: check if this is correct
-	)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		forEachCtxCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			switch sel.Sel.Name {
+			case "Context":
+				if len(call.Args) == 0 {
+					f.Replace(sel.Sel, "RequestCtx")
+				}
+			case "UserContext":
+				f.Replace(sel.Sel, "Context")
+			case "SetUserContext":
+				f.Replace(sel.Sel, "SetContext")
+			}
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate context methods: %w", err)
@@ -110,11 +297,13 @@ func MigrateContextMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateViewBind replaces the old Ctx.Bind view binding helper with ViewBind
 func MigrateViewBind(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	// Replace .Bind() with arguments, not the Bind() from the binding package
-	reViewBind := regexp.MustCompile(`\.Bind\(([^)]+)\)`)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return reViewBind.ReplaceAllString(content, ".ViewBind($1)")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		forEachCtxCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			// Bind() without arguments is the new binding API
+			if sel.Sel.Name == "Bind" && len(call.Args) > 0 {
+				f.Replace(sel.Sel, "ViewBind")
+			}
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate ViewBind calls: %w", err)
@@ -126,10 +315,15 @@ func MigrateViewBind(cmd *cobra.Command, cwd string, _, _ *semver.Version) error
 
 // MigrateMount replaces app.Mount with app.Use
 func MigrateMount(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(".Mount(", ".Use(")
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		if _, _, ok := fiberImport(f); !ok {
+			return
+		}
+		forEachAppCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if sel.Sel.Name == "Mount" && len(call.Args) == 2 {
+				f.Replace(sel.Sel, "Use")
+			}
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate Mount usages: %w", err)
@@ -141,10 +335,21 @@ func MigrateMount(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
 
 // MigrateAddMethod adapts the Add method signature
 func MigrateAddMethod(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	re := regexp.MustCompile(`\.Add\(\s*([^,\n]+)\s*,`)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return re.ReplaceAllString(content, ".Add([]string{$1},")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		if _, _, ok := fiberImport(f); !ok {
+			return
+		}
+		forEachAppCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if sel.Sel.Name != "Add" || len(call.Args) < 3 {
+				return
+			}
+			// already a list of methods
+			if _, ok := call.Args[0].(*ast.CompositeLit); ok {
+				return
+			}
+			f.InsertAt(call.Args[0].Pos(), "[]string{")
+			f.InsertAt(call.Args[0].End(), "}")
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate Add method calls: %w", err)
@@ -156,32 +361,31 @@ func MigrateAddMethod(cmd *cobra.Command, cwd string, _, _ *semver.Version) erro
 
 // MigrateCORSConfig updates cors middleware configuration fields
 func MigrateCORSConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	reOrigins := regexp.MustCompile(`AllowOrigins:\s*"([^"]*)"`)
-	reMethods := regexp.MustCompile(`AllowMethods:\s*"([^"]*)"`)
-	reHeaders := regexp.MustCompile(`AllowHeaders:\s*"([^"]*)"`)
-	reExpose := regexp.MustCompile(`ExposeHeaders:\s*"([^"]*)"`)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		conv := func(src string, re *regexp.Regexp, field string) string {
-			return re.ReplaceAllStringFunc(src, func(s string) string {
-				matches := re.FindStringSubmatch(s)
-				if len(matches) < 2 {
-					return s
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		corsName, ok := middlewareImport(f, "cors")
+		if !ok {
+			return
+		}
+		forEachConfig(f, corsName, func(lit *ast.CompositeLit) {
+			kvs := fields(lit)
+			for _, field := range []string{"AllowOrigins", "AllowMethods", "AllowHeaders", "ExposeHeaders"} {
+				kv, ok := kvs[field]
+				if !ok {
+					continue
 				}
-				parts := strings.Split(matches[1], ",")
-				for i, p := range parts {
-					parts[i] = fmt.Sprintf("%q", strings.TrimSpace(p))
+				val, ok := stringLit(kv.Value)
+				if !ok {
+					continue
 				}
-				return fmt.Sprintf("%s: []string{%s}", field, strings.Join(parts, ", "))
-			})
-		}
-
-		content = conv(content, reOrigins, "AllowOrigins")
-		content = conv(content, reMethods, "AllowMethods")
-		content = conv(content, reHeaders, "AllowHeaders")
-		content = conv(content, reExpose, "ExposeHeaders")
-
-		return content
+				var parts []string
+				for _, p := range strings.Split(val, ",") {
+					if p = strings.TrimSpace(p); p != "" {
+						parts = append(parts, strconv.Quote(p))
+					}
+				}
+				f.Replace(kv.Value, "[]string{"+strings.Join(parts, ", ")+"}")
+			}
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate CORS configs: %w", err)
@@ -191,43 +395,54 @@ func MigrateCORSConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) err
 	return nil
 }
 
+// stringLit returns the value of a string literal expression.
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	val, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
+
 // MigrateCSRFConfig updates csrf middleware configuration fields
 func MigrateCSRFConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer("Expiration:", "IdleTimeout:")
-	reSession := regexp.MustCompile(`\s*SessionKey:\s*[^,]+,?\n`)
-	reKeyLookup := regexp.MustCompile(`(\s*)KeyLookup:\s*([^,\n]+)(,?)(\n?)`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = replacer.Replace(content)
-		content = reSession.ReplaceAllString(content, "")
-
-		content = reKeyLookup.ReplaceAllStringFunc(content, func(s string) string {
-			sub := reKeyLookup.FindStringSubmatch(s)
-			indent := sub[1]
-			val := strings.TrimSpace(sub[2])
-			comma := sub[3]
-			newline := sub[4]
-
-			if uq, err := strconv.Unquote(val); err == nil {
-				val = uq
-			}
-
-			var extractor string
-			switch {
-			case strings.HasPrefix(val, "header:"):
-				extractor = fmt.Sprintf("Extractor: csrf.FromHeader(%q)", strings.TrimPrefix(val, "header:"))
-			case strings.HasPrefix(val, "form:"):
-				extractor = fmt.Sprintf("Extractor: csrf.FromForm(%q)", strings.TrimPrefix(val, "form:"))
-			case strings.HasPrefix(val, "query:"):
-				extractor = fmt.Sprintf("Extractor: csrf.FromQuery(%q)", strings.TrimPrefix(val, "query:"))
-			default:
-				// Unsupported or insecure value (e.g. cookie) - remove
-				return ""
-			}
+	extractors := []struct{ prefix, fn string }{
+		{"header:", "FromHeader"},
+		{"form:", "FromForm"},
+		{"query:", "FromQuery"},
+	}
 
-			return fmt.Sprintf("%s%s%s%s", indent, extractor, comma, newline)
-		})
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		csrfName, ok := middlewareImport(f, "csrf")
+		if !ok {
+			return
+		}
+		forEachConfig(f, csrfName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{"Expiration": "IdleTimeout"})
+			removeFields(f, lit, map[string]string{
+				"SessionKey": "Configure the Session store and read the token with csrf.TokenFromContext",
+			})
 
-		return content
+			kv, ok := fields(lit)["KeyLookup"]
+			if !ok {
+				return
+			}
+			val, _ := stringLit(kv.Value)
+			for _, e := range extractors {
+				if strings.HasPrefix(val, e.prefix) {
+					f.Replace(kv, fmt.Sprintf("Extractor: %s.%s(%q)", csrfName, e.fn, strings.TrimPrefix(val, e.prefix)))
+					return
+				}
+			}
+			// Unsupported or insecure value (e.g. cookie) - remove
+			f.Delete(kv)
+			f.Report(kv, fmt.Sprintf("removed %s.KeyLookup %q", f.Source(lit.Type), val),
+				"Configure an Extractor such as csrf.FromHeader, cookie lookups are no longer supported")
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate CSRF configs: %w", err)
@@ -237,26 +452,65 @@ func MigrateCSRFConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) err
 	return nil
 }
 
-// MigrateMonitorImport updates monitor middleware import path
+// MigrateMonitorImport moves the monitor middleware, removed from Fiber v3,
+// to its contrib release and requires that release in go.mod.
 func MigrateMonitorImport(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	re := regexp.MustCompile(`github\.com/gofiber/fiber/([^/]+)/middleware/monitor`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return re.ReplaceAllString(content, "github.com/gofiber/contrib/monitor")
+	monitor, _ := contribModuleOf("github.com/gofiber/contrib/monitor")
+
+	used := false
+	err := internal.ChangeFiles(cmd.Context(), cwd, func(files map[string][]byte) (map[string][]byte, error) {
+		changed := make(map[string][]byte)
+		for path, content := range files {
+			f, err := internal.ParseGoFile(path, content)
+			if err != nil {
+				continue // not valid Go, nothing to migrate
+			}
+			for _, spec := range f.File.Imports {
+				m := middlewarePathRegexp.FindStringSubmatch(internal.ImportPath(spec))
+				if m != nil && m[2] == "monitor" {
+					f.Replace(spec.Path, strconv.Quote(monitor.NewPath))
+				}
+			}
+			out, err := f.Apply()
+			if err != nil {
+				return nil, fmt.Errorf("apply edits to %s: %w", path, err)
+			}
+			if !bytes.Equal(out, content) {
+				changed[path] = out
+				used = true
+			}
+		}
+		return changed, nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate monitor import: %w", err)
 	}
 
+	if used {
+		if err := requireContribModule(cmd, cwd, monitor); err != nil {
+			return err
+		}
+	}
+
 	cmd.Println("Migrating monitor middleware import")
 	return nil
 }
 
 // MigrateProxyTLSConfig updates proxy TLS helper to new client configuration
 func MigrateProxyTLSConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	re := regexp.MustCompile(`proxy\.WithTlsConfig\(([^)]+)\)`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return re.ReplaceAllString(content,
-			"proxy.WithClient(&fasthttp.Client{TLSConfig: $1})")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		proxyName, ok := middlewareImport(f, "proxy")
+		if !ok {
+			return
+		}
+		forEachMethodCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if !isSelector(sel, proxyName, "WithTlsConfig") || len(call.Args) != 1 {
+				return
+			}
+			fasthttpName := f.AddImport("github.com/valyala/fasthttp")
+			f.Replace(call, fmt.Sprintf("%s.WithClient(&%s.Client{TLSConfig: %s})",
+				proxyName, fasthttpName, f.Source(call.Args[0])))
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate proxy TLS config: %w", err)
@@ -268,13 +522,17 @@ func MigrateProxyTLSConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateMimeConstants updates deprecated MIME constants
 func MigrateMimeConstants(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		"MIMEApplicationJavaScriptCharsetUTF8", "MIMETextJavaScriptCharsetUTF8",
-		"MIMEApplicationJavaScript", "MIMETextJavaScript",
-	)
+	renames := map[string]string{
+		"MIMEApplicationJavaScriptCharsetUTF8": "MIMETextJavaScriptCharsetUTF8",
+		"MIMEApplicationJavaScript":            "MIMETextJavaScript",
+	}
 
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		renameSelectors(f, fiberName, renames)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate MIME constants: %w", err)
@@ -284,10 +542,28 @@ func MigrateMimeConstants(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 	return nil
 }
 
+// renameSelectors renames pkg.Name references according to renames.
+func renameSelectors(f *internal.GoFile, pkg string, renames map[string]string) {
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if to, ok := renames[sel.Sel.Name]; ok && isSelector(sel, pkg, sel.Sel.Name) {
+			f.Replace(sel.Sel, to)
+		}
+		return true
+	})
+}
+
 // MigrateLoggerTags updates deprecated logger tag constants
 func MigrateLoggerTags(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return strings.ReplaceAll(content, "logger.TagHeader", "logger.TagReqHeader")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		loggerName, ok := middlewareImport(f, "logger")
+		if !ok {
+			return
+		}
+		renameSelectors(f, loggerName, map[string]string{"TagHeader": "TagReqHeader"})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate logger tags: %w", err)
@@ -299,17 +575,18 @@ func MigrateLoggerTags(cmd *cobra.Command, cwd string, _, _ *semver.Version) err
 
 // MigrateStaticRoutes replaces app.Static calls with static middleware
 func MigrateStaticRoutes(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		re := regexp.MustCompile(`\.Static\(\s*("[^"]*")\s*,\s*("[^"]*")(?:,\s*([^)]*))?\)`)
-		return re.ReplaceAllStringFunc(content, func(m string) string {
-			sub := re.FindStringSubmatch(m)
-			pathLit := sub[1]
-			root := sub[2]
-			cfg := sub[3]
-
-			path, err := strconv.Unquote(pathLit)
-			if err != nil {
-				path = strings.Trim(pathLit, "\"")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, fiberPath, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachAppCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if sel.Sel.Name != "Static" || len(call.Args) < 2 || len(call.Args) > 3 {
+				return
+			}
+			path, ok := stringLit(call.Args[0])
+			if !ok {
+				return
 			}
 
 			switch path {
@@ -321,17 +598,14 @@ func MigrateStaticRoutes(cmd *cobra.Command, cwd string, _, _ *semver.Version) e
 				path += "*"
 			}
 
-			quoted := strconv.Quote(path)
-
-			if cfg != "" {
-				cfg = strings.TrimSpace(cfg)
-				cfg = strings.Replace(cfg, "Static{", "static.Config{", 1)
-				reIndex := regexp.MustCompile(`Index:\s*([^,}\n]+)`)
-				cfg = reIndex.ReplaceAllString(cfg, "IndexNames: []string{$1}")
-				return fmt.Sprintf(".Get(%s, static.New(%s, %s))", quoted, root, cfg)
+			staticName := f.AddImport(middlewarePath(fiberPath, "static"))
+			args := f.Source(call.Args[1])
+			if len(call.Args) == 3 {
+				args += ", " + staticConfig(f, call.Args[2], fiberName, staticName)
 			}
 
-			return fmt.Sprintf(".Get(%s, static.New(%s))", quoted, root)
+			f.ReplaceRange(sel.Sel.Pos(), call.End(),
+				fmt.Sprintf("Get(%s, %s.New(%s))", strconv.Quote(path), staticName, args))
 		})
 	})
 	if err != nil {
@@ -342,15 +616,41 @@ func MigrateStaticRoutes(cmd *cobra.Command, cwd string, _, _ *semver.Version) e
 	return nil
 }
 
+// staticConfig converts a fiber.Static literal to a static.Config literal.
+func staticConfig(f *internal.GoFile, expr ast.Expr, fiberName, staticName string) string {
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return f.Source(expr)
+	}
+	if id, ok := lit.Type.(*ast.Ident); !(ok && id.Name == "Static") && !isSelector(lit.Type, fiberName, "Static") {
+		return f.Source(expr)
+	}
+
+	edits := []internal.Edit{{Pos: lit.Type.Pos(), End: lit.Type.End(), Text: staticName + ".Config"}}
+	if kv, ok := fields(lit)["Index"]; ok {
+		edits = append(edits, internal.Edit{
+			Pos: kv.Pos(), End: kv.End(),
+			Text: "IndexNames: []string{" + f.Source(kv.Value) + "}",
+		})
+	}
+
+	return f.SourceWith(lit, edits...)
+}
+
 // MigrateTrustedProxyConfig updates trusted proxy configuration options
 func MigrateTrustedProxyConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	reEnable := regexp.MustCompile(`EnableTrustedProxyCheck`)
-	reProxies := regexp.MustCompile(`TrustedProxies:\s*([^,\n]+),`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = reEnable.ReplaceAllString(content, "TrustProxy")
-		content = reProxies.ReplaceAllString(content, "TrustProxyConfig: fiber.TrustProxyConfig{Proxies: $1},")
-
-		return content
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachConfig(f, fiberName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{"EnableTrustedProxyCheck": "TrustProxy"})
+			if kv, ok := fields(lit)["TrustedProxies"]; ok {
+				f.Replace(kv, fmt.Sprintf("TrustProxyConfig: %s.TrustProxyConfig{Proxies: %s}",
+					fiberName, f.Source(kv.Value)))
+			}
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate trusted proxy config: %w", err)
@@ -364,12 +664,17 @@ func MigrateTrustedProxyConfig(cmd *cobra.Command, cwd string, _, _ *semver.Vers
 // in Fiber v3. It renames Prefork and Network fields and adapts them to the new
 // listener configuration fields.
 func MigrateConfigListenerFields(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		replacer := strings.NewReplacer(
-			"Prefork:", "EnablePrefork:",
-			"Network:", "ListenerNetwork:",
-		)
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachConfig(f, fiberName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{
+				"Prefork": "EnablePrefork",
+				"Network": "ListenerNetwork",
+			})
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate listener related config fields: %w", err)
@@ -382,13 +687,17 @@ func MigrateConfigListenerFields(cmd *cobra.Command, cwd string, _, _ *semver.Ve
 // MigrateListenerCallbacks removes deprecated OnShutdown callbacks from
 // ListenerConfig. Fiber v3 replaces these with the OnPostShutdown hook.
 func MigrateListenerCallbacks(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	reErr := regexp.MustCompile(`\s*OnShutdownError:\s*[^,]+,?\n`)
-	reSuccess := regexp.MustCompile(`\s*OnShutdownSuccess:\s*[^,]+,?\n`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = reErr.ReplaceAllString(content, "")
-		content = reSuccess.ReplaceAllString(content, "")
-
-		return content
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachConfig(f, fiberName, func(lit *ast.CompositeLit) {
+			removeFields(f, lit, map[string]string{
+				"OnShutdownError":   "Handle shutdown errors in an app.Hooks().OnPostShutdown hook",
+				"OnShutdownSuccess": "Move the callback to an app.Hooks().OnPostShutdown hook",
+			})
+		}, "ListenConfig", "ListenerConfig")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate listener callbacks: %w", err)
@@ -400,29 +709,83 @@ func MigrateListenerCallbacks(cmd *cobra.Command, cwd string, _, _ *semver.Versi
 
 // MigrateFilesystemMiddleware replaces deprecated filesystem middleware with static middleware
 func MigrateFilesystemMiddleware(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = strings.ReplaceAll(content,
-			"github.com/gofiber/fiber/v2/middleware/filesystem",
-			"github.com/gofiber/fiber/v3/middleware/static")
-		content = strings.ReplaceAll(content,
-			"github.com/gofiber/fiber/v3/middleware/filesystem",
-			"github.com/gofiber/fiber/v3/middleware/static")
-
-		reNew := regexp.MustCompile(`filesystem\.New\s*\(`)
-		content = reNew.ReplaceAllString(content, `static.New("", `)
-
-		content = strings.ReplaceAll(content, "filesystem.Config{", "static.Config{")
-
-		reRootHTTP := regexp.MustCompile(`Root:\s*http.Dir\(([^)]+)\)`)
-		content = reRootHTTP.ReplaceAllString(content, `FS: os.DirFS($1)`)
-
-		reRoot := regexp.MustCompile(`Root:\s*([^,\n]+)`)
-		content = reRoot.ReplaceAllString(content, `FS: os.DirFS($1)`)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		spec := f.FindImport(func(p string) bool {
+			m := middlewarePathRegexp.FindStringSubmatch(p)
+			return m != nil && m[2] == "filesystem"
+		})
+		if spec == nil {
+			return
+		}
+		fsName := internal.ImportName(spec)
+		// the fiber module of the filesystem import, already rewritten to the
+		// target major version by MigrateGoPkgs
+		fiberPath := "github.com/gofiber/fiber" + middlewarePathRegexp.FindStringSubmatch(internal.ImportPath(spec))[1]
+		f.Replace(spec.Path, strconv.Quote(middlewarePath(fiberPath, "static")))
+
+		// keep aliased imports, otherwise the package is now called static
+		staticName := fsName
+		if spec.Name == nil {
+			staticName = "static"
+		}
 
-		reIndex := regexp.MustCompile(`Index:\s*([^,\n]+)`)
-		content = reIndex.ReplaceAllString(content, `IndexNames: []string{$1}`)
+		httpName := ""
+		if httpSpec := f.FindImport(func(p string) bool { return p == "net/http" }); httpSpec != nil {
+			httpName = internal.ImportName(httpSpec)
+		}
+		httpUses, httpRewritten := 0, 0
+
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if isSelector(n.Fun, fsName, "New") {
+					if len(n.Args) == 0 {
+						f.InsertAt(n.Rparen, `""`)
+					} else {
+						f.InsertAt(n.Args[0].Pos(), `"", `)
+					}
+				}
+			case *ast.CompositeLit:
+				if !isSelector(n.Type, fsName, "Config") {
+					return true
+				}
+				kvs := fields(n)
+				if kv, ok := kvs["Root"]; ok {
+					root := f.Source(kv.Value)
+					if call, ok := kv.Value.(*ast.CallExpr); ok && len(call.Args) == 1 {
+						switch {
+						case isSelector(call.Fun, httpName, "Dir"):
+							root = f.AddImport("os") + ".DirFS(" + f.Source(call.Args[0]) + ")"
+							httpRewritten++
+						case isSelector(call.Fun, httpName, "FS"):
+							root = f.Source(call.Args[0])
+							httpRewritten++
+						}
+					}
+					f.Replace(kv, "FS: "+root)
+				}
+				if kv, ok := kvs["Index"]; ok {
+					f.Replace(kv, "IndexNames: []string{"+f.Source(kv.Value)+"}")
+				}
+			case *ast.SelectorExpr:
+				if id, ok := n.X.(*ast.Ident); ok {
+					switch id.Name {
+					case fsName:
+						if staticName != fsName {
+							f.Replace(id, staticName)
+						}
+					case httpName:
+						httpUses++
+					}
+				}
+			}
+			return true
+		})
 
-		return content
+		// drop net/http if it was only used for the filesystem root
+		if httpName != "" && httpUses > 0 && httpUses == httpRewritten {
+			f.DeleteImport(f.FindImport(func(p string) bool { return p == "net/http" }))
+		}
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate filesystem middleware: %w", err)
@@ -434,9 +797,16 @@ func MigrateFilesystemMiddleware(cmd *cobra.Command, cwd string, _, _ *semver.Ve
 
 // MigrateEnvVarConfig removes deprecated ExcludeVars field from envvar middleware configuration
 func MigrateEnvVarConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	re := regexp.MustCompile(`\s*ExcludeVars:\s*[^,]+,?\n`)
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return re.ReplaceAllString(content, "")
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		envvarName, ok := middlewareImport(f, "envvar")
+		if !ok {
+			return
+		}
+		forEachConfig(f, envvarName, func(lit *ast.CompositeLit) {
+			removeFields(f, lit, map[string]string{
+				"ExcludeVars": "List the variables to expose in ExportVars",
+			})
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate EnvVar configs: %w", err)
@@ -448,19 +818,19 @@ func MigrateEnvVarConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) e
 
 // MigrateHealthcheckConfig updates healthcheck middleware configuration fields
 func MigrateHealthcheckConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		content = strings.ReplaceAll(content, "LivenessProbe:", "Probe:")
-
-		re := regexp.MustCompile(`\s*ReadinessProbe:\s*[^,]+,?\n`)
-		content = re.ReplaceAllString(content, "")
-
-		re = regexp.MustCompile(`\s*LivenessEndpoint:\s*[^,]+,?\n?`)
-		content = re.ReplaceAllString(content, "")
-
-		re = regexp.MustCompile(`\s*ReadinessEndpoint:\s*[^,]+,?\n?`)
-		content = re.ReplaceAllString(content, "")
-
-		return content
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		healthcheckName, ok := middlewareImport(f, "healthcheck")
+		if !ok {
+			return
+		}
+		forEachConfig(f, healthcheckName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{"LivenessProbe": "Probe"})
+			removeFields(f, lit, map[string]string{
+				"ReadinessProbe":    "Register the probe with app.Get(healthcheck.ReadinessEndpoint, healthcheck.New(healthcheck.Config{Probe: ...}))",
+				"LivenessEndpoint":  "Register the handler on the endpoint with app.Get",
+				"ReadinessEndpoint": "Register the handler on the endpoint with app.Get",
+			})
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate healthcheck configs: %w", err)
@@ -472,14 +842,18 @@ func MigrateHealthcheckConfig(cmd *cobra.Command, cwd string, _, _ *semver.Versi
 
 // MigrateLimiterConfig updates limiter middleware configuration fields
 func MigrateLimiterConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		reConfig := regexp.MustCompile(`limiter\.Config{[^}]*}`)
-		return reConfig.ReplaceAllStringFunc(content, func(s string) string {
-			s = strings.ReplaceAll(s, "Duration:", "Expiration:")
-			s = strings.ReplaceAll(s, "Store:", "Storage:")
-			s = strings.ReplaceAll(s, "Key:", "KeyGenerator:")
-			return s
-		})
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		limiterName, ok := middlewareImport(f, "limiter")
+		if !ok {
+			return
+		}
+		forEachConfig(f, limiterName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{
+				"Duration": "Expiration",
+				"Store":    "Storage",
+				"Key":      "KeyGenerator",
+			})
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate limiter configs: %w", err)
@@ -491,12 +865,14 @@ func MigrateLimiterConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateSessionConfig updates session middleware configuration fields
 func MigrateSessionConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		reConfig := regexp.MustCompile(`session\.Config{[^}]*}`)
-		return reConfig.ReplaceAllStringFunc(content, func(s string) string {
-			s = strings.ReplaceAll(s, "Expiration:", "IdleTimeout:")
-			return s
-		})
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		sessionName, ok := middlewareImport(f, "session")
+		if !ok {
+			return
+		}
+		forEachConfig(f, sessionName, func(lit *ast.CompositeLit) {
+			renameFields(f, lit, map[string]string{"Expiration": "IdleTimeout"})
+		}, "Config")
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate session configs: %w", err)
@@ -508,9 +884,21 @@ func MigrateSessionConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateAppTestConfig updates app.Test calls to use the new TestConfig parameter
 func MigrateAppTestConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		re := regexp.MustCompile(`\.Test\(([^,\n]+),\s*([^\n)]+)\)`)
-		return re.ReplaceAllString(content, `.Test($1, fiber.TestConfig{Timeout: $2})`)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachAppCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if sel.Sel.Name != "Test" || len(call.Args) != 2 {
+				return
+			}
+			// already a TestConfig
+			if _, ok := call.Args[1].(*ast.CompositeLit); ok {
+				return
+			}
+			f.Replace(call.Args[1], fmt.Sprintf("%s.TestConfig{Timeout: %s}", fiberName, f.Source(call.Args[1])))
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate app.Test calls: %w", err)
@@ -522,23 +910,36 @@ func MigrateAppTestConfig(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateMiddlewareLocals replaces Locals lookups for middleware data with helper functions
 func MigrateMiddlewareLocals(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		replacements := []struct {
-			re   *regexp.Regexp
-			repl string
-		}{
-			{regexp.MustCompile(`(\w+)\.Locals\("requestid"\)`), `requestid.FromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("csrf"\)`), `csrf.TokenFromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("csrf_handler"\)`), `csrf.HandlerFromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("session"\)`), `session.FromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("username"\)`), `basicauth.UsernameFromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("password"\)`), `basicauth.PasswordFromContext($1)`},
-			{regexp.MustCompile(`(\w+)\.Locals\("token"\)`), `keyauth.TokenFromContext($1)`},
-		}
-		for _, r := range replacements {
-			content = r.re.ReplaceAllString(content, r.repl)
-		}
-		return content
+	helpers := map[string]struct{ pkg, fn string }{
+		"requestid":    {"requestid", "FromContext"},
+		"csrf":         {"csrf", "TokenFromContext"},
+		"csrf_handler": {"csrf", "HandlerFromContext"},
+		"session":      {"session", "FromContext"},
+		"username":     {"basicauth", "UsernameFromContext"},
+		"password":     {"basicauth", "PasswordFromContext"},
+		"token":        {"keyauth", "TokenFromContext"},
+	}
+
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		_, fiberPath, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachCtxCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if sel.Sel.Name != "Locals" || len(call.Args) != 1 {
+				return
+			}
+			key, ok := stringLit(call.Args[0])
+			if !ok {
+				return
+			}
+			h, ok := helpers[key]
+			if !ok {
+				return
+			}
+			pkgName := f.AddImport(middlewarePath(fiberPath, h.pkg))
+			f.Replace(call, fmt.Sprintf("%s.%s(%s)", pkgName, h.fn, f.Source(sel.X)))
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate middleware locals: %w", err)
@@ -548,17 +949,43 @@ func MigrateMiddlewareLocals(cmd *cobra.Command, cwd string, _, _ *semver.Versio
 	return nil
 }
 
-// MigrateListenMethods replaces removed Listen helpers with Listen
+// MigrateListenMethods replaces removed Listen helpers with Listen. The
+// certificate files of ListenTLS and ListenMutualTLS move to a
+// fiber.ListenConfig, calls with a tls.Certificate are reported.
 func MigrateListenMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		".ListenTLSWithCertificate(", ".Listen(",
-		".ListenTLS(", ".Listen(",
-		".ListenMutualTLSWithCertificate(", ".Listen(",
-		".ListenMutualTLS(", ".Listen(",
-	)
+	// fields of fiber.ListenConfig taking the arguments after the address
+	listeners := map[string][]string{
+		"ListenTLS":       {"CertFile", "CertKeyFile"},
+		"ListenMutualTLS": {"CertFile", "CertKeyFile", "CertClientFile"},
+	}
+	withCertificate := map[string]bool{
+		"ListenTLSWithCertificate":       true,
+		"ListenMutualTLSWithCertificate": true,
+	}
 
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		fiberName, _, ok := fiberImport(f)
+		if !ok {
+			return
+		}
+		forEachMethodCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+			if withCertificate[sel.Sel.Name] {
+				f.Report(call, "removed "+sel.Sel.Name,
+					"Call Listen with a "+fiberName+".ListenConfig and set the certificate in its TLSConfigFunc")
+				return
+			}
+			names, ok := listeners[sel.Sel.Name]
+			if !ok || len(call.Args) != len(names)+1 || call.Ellipsis.IsValid() {
+				return
+			}
+			fields := make([]string, len(names))
+			for i, name := range names {
+				fields[i] = name + ": " + f.Source(call.Args[i+1])
+			}
+			f.Replace(sel.Sel, "Listen")
+			f.ReplaceRange(call.Args[1].Pos(), call.Args[len(names)].End(),
+				fiberName+".ListenConfig{"+strings.Join(fields, ", ")+"}")
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate listen methods: %w", err)
@@ -570,12 +997,8 @@ func MigrateListenMethods(cmd *cobra.Command, cwd string, _, _ *semver.Version)
 
 // MigrateReqHeaderParser replaces the deprecated ReqHeaderParser helper with the new binding API
 func MigrateReqHeaderParser(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
-	replacer := strings.NewReplacer(
-		".ReqHeaderParser(", ".Bind().Header(",
-	)
-
-	err := internal.ChangeFileContent(cwd, func(content string) string {
-		return replacer.Replace(content)
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		renameCtxMethods(f, map[string]string{"ReqHeaderParser": "Bind().Header"})
 	})
 	if err != nil {
 		return fmt.Errorf("failed to migrate ReqHeaderParser: %w", err)
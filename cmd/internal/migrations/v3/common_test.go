@@ -2,11 +2,13 @@ package v3_test
 
 import (
 	"bytes"
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"strings"
 	"testing"
 
+	"github.com/gofiber/cli/cmd/internal"
 	v3 "github.com/gofiber/cli/cmd/internal/migrations/v3" //nolint:revive // alias required
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -57,6 +59,123 @@ func handler(c *fiber.Ctx) error { return nil }
 	assert.Contains(t, buf.String(), "Migrating handler signatures")
 }
 
+func Test_MigrateHandlerSignatures_Deref(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := writeTempFile(t, dir, `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func handler(c *fiber.Ctx) error {
+	copied := *c
+	_ = copied
+	return nil
+}
+`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	report := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(context.Background(), report))
+	require.NoError(t, v3.MigrateHandlerSignatures(cmd, dir, nil, nil))
+	require.NoError(t, v3.MigrateCtxTypes(cmd, dir, nil, nil))
+
+	assert.Contains(t, readFile(t, file), "func handler(c fiber.Ctx) error {")
+
+	findings := report.Findings()
+	require.Len(t, findings, 1)
+	assert.Equal(t, "dereferenced *fiber.Ctx c", findings[0].Message)
+	assert.Equal(t, 6, findings[0].Line)
+}
+
+func Test_MigrateCtxTypes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := writeTempFile(t, dir, `package main
+
+import "github.com/gofiber/fiber/v2"
+
+type (
+	Ctx     = fiber.Ctx
+	Handler = func(*fiber.Ctx) error
+)
+
+type server struct {
+	ctx     *fiber.Ctx
+	byID    map[string]*fiber.Ctx
+	pending chan *fiber.Ctx
+	all     []*Ctx
+}
+
+type Getter interface {
+	Get(c *fiber.Ctx, key string) (*fiber.Ctx, error)
+}
+
+type Pool[T any] struct{ items []T }
+
+var pool Pool[*fiber.Ctx]
+
+func current(v any) *fiber.Ctx {
+	c, _ := v.(*fiber.Ctx)
+	copied := *c
+	_ = copied
+	_ = &fiber.Ctx{}
+	_ = new(fiber.Ctx)
+	return c
+}
+`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	report := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(context.Background(), report))
+	require.NoError(t, v3.MigrateCtxTypes(cmd, dir, nil, nil))
+
+	assert.Equal(t, `package main
+
+import "github.com/gofiber/fiber/v2"
+
+type (
+	Ctx     = fiber.Ctx
+	Handler = func(fiber.Ctx) error
+)
+
+type server struct {
+	ctx     fiber.Ctx
+	byID    map[string]fiber.Ctx
+	pending chan fiber.Ctx
+	all     []Ctx
+}
+
+type Getter interface {
+	Get(c fiber.Ctx, key string) (fiber.Ctx, error)
+}
+
+type Pool[T any] struct{ items []T }
+
+var pool Pool[fiber.Ctx]
+
+func current(v any) fiber.Ctx {
+	c, _ := v.(fiber.Ctx)
+	copied := *c
+	_ = copied
+	_ = &fiber.Ctx{}
+	_ = new(fiber.Ctx)
+	return c
+}
+`, readFile(t, file))
+	assert.Contains(t, buf.String(), "Migrating Ctx types")
+
+	findings := report.Findings()
+	require.Len(t, findings, 3)
+	assert.Equal(t, "dereferenced *fiber.Ctx c", findings[0].Message)
+	assert.Equal(t, 27, findings[0].Line)
+	assert.Equal(t, "fiber.Ctx literal", findings[1].Message)
+	assert.Equal(t, "new(fiber.Ctx)", findings[2].Message)
+}
+
 func Test_MigrateParserMethods(t *testing.T) {
 	t.Parallel()
 
@@ -170,9 +289,9 @@ func handler(c fiber.Ctx) error {
 	require.NoError(t, v3.MigrateContextMethods(cmd, dir, nil, nil))
 
 	content := readFile(t, file)
-	assert.Contains(t, content, ".RequestCtx()")
-	assert.Contains(t, content, ".Context()")
-	assert.Contains(t, content, ".SetContext(")
+	assert.Contains(t, content, "ctx := c.RequestCtx()")
+	assert.Contains(t, content, "uc := c.Context()")
+	assert.Contains(t, content, "c.SetContext(ctx)")
 	assert.Contains(t, buf.String(), "Migrating context methods")
 }
 
@@ -426,15 +545,52 @@ var _ = csrf.New(csrf.Config{
 	assert.Contains(t, buf.String(), "Migrating CSRF middleware configs")
 }
 
-func Test_MigrateMonitorImport(t *testing.T) {
+func Test_MigrateCSRFConfig_Findings(t *testing.T) {
 	t.Parallel()
 
-	dir, err := os.MkdirTemp("", "mmonitor")
-	require.NoError(t, err)
-	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+	dir := t.TempDir()
+	file := writeTempFile(t, dir, `package main
+
+import "github.com/gofiber/fiber/v2/middleware/csrf"
+
+var _ = csrf.New(csrf.Config{
+	SessionKey: "csrf",
+	KeyLookup:  "cookie:csrf_",
+})
+`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	report := internal.NewReport(true)
+	cmd.SetContext(internal.WithReport(context.Background(), report))
+	require.NoError(t, v3.MigrateCSRFConfig(cmd, dir, nil, nil))
+
+	assert.Equal(t, `package main
+
+import "github.com/gofiber/fiber/v2/middleware/csrf"
+
+var _ = csrf.New(csrf.Config{
+	// TODO(fiber-migrate): removed csrf.Config.SessionKey. Configure the Session store and read the token with csrf.TokenFromContext
+	// TODO(fiber-migrate): removed csrf.Config.KeyLookup "cookie:csrf_". Configure an Extractor such as csrf.FromHeader, cookie lookups are no longer supported
+})
+`, readFile(t, file))
+
+	findings := report.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, file, findings[0].File)
+	assert.Equal(t, 6, findings[0].Line)
+	assert.Equal(t, "removed csrf.Config.SessionKey", findings[0].Message)
+	assert.Equal(t, 7, findings[1].Line)
+}
 
+func Test_MigrateMonitorImport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	modFile := filepath.Join(dir, "go.mod")
+	require.NoError(t, os.WriteFile(modFile, []byte("module example\n\ngo 1.24\n\nrequire github.com/gofiber/fiber/v3 v3.0.0\n"), 0o600))
 	file := writeTempFile(t, dir, `package main
-import "github.com/gofiber/fiber/v2/middleware/monitor"
+import "github.com/gofiber/fiber/v3/middleware/monitor"
 var _ = monitor.New()`)
 
 	var buf bytes.Buffer
@@ -442,9 +598,18 @@ var _ = monitor.New()`)
 	require.NoError(t, v3.MigrateMonitorImport(cmd, dir, nil, nil))
 
 	content := readFile(t, file)
-	assert.Contains(t, content, "github.com/gofiber/contrib/monitor")
-	assert.NotContains(t, content, "fiber/v2/middleware/monitor")
+	assert.Contains(t, content, `"github.com/gofiber/contrib/v3/monitor"`)
+	assert.NotContains(t, content, "fiber/v3/middleware/monitor")
+	assert.Contains(t, readFile(t, modFile), "github.com/gofiber/contrib/v3/monitor v1.0.0")
 	assert.Contains(t, buf.String(), "Migrating monitor middleware import")
+
+	// go.mod is left alone if the monitor is not used
+	other := t.TempDir()
+	otherMod := filepath.Join(other, "go.mod")
+	require.NoError(t, os.WriteFile(otherMod, []byte("module example\n\ngo 1.24\n"), 0o600))
+	writeTempFile(t, other, "package main\n")
+	require.NoError(t, v3.MigrateMonitorImport(cmd, other, nil, nil))
+	assert.Equal(t, "module example\n\ngo 1.24\n", readFile(t, otherMod))
 }
 
 func Test_MigrateProxyTLSConfig(t *testing.T) {
@@ -536,35 +701,100 @@ func main() {
 func Test_MigrateListenMethods(t *testing.T) {
 	t.Parallel()
 
-	dir, err := os.MkdirTemp("", "mlisten")
-	require.NoError(t, err)
-	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
-
+	dir := t.TempDir()
 	file := writeTempFile(t, dir, `package main
+
 import (
-    "github.com/gofiber/fiber/v2"
-    "crypto/tls"
+	"crypto/tls"
+
+	"github.com/gofiber/fiber/v3"
 )
+
 func main() {
-    app := fiber.New()
-    cert, _ := tls.LoadX509KeyPair("cert.pem", "key.pem")
-    app.ListenTLS(":443", "cert.pem", "key.pem")
-    app.ListenTLSWithCertificate(":443", cert)
-    app.ListenMutualTLS(":443", "cert.pem", "key.pem", "ca.pem")
-    app.ListenMutualTLSWithCertificate(":443", cert, "ca.pem")
-}`)
+	app := fiber.New()
+	cert, _ := tls.LoadX509KeyPair("cert.pem", "key.pem")
+	app.ListenTLS(":443", "cert.pem", "key.pem")
+	app.ListenTLSWithCertificate(":443", cert)
+	app.ListenMutualTLS(":443", "cert.pem", "key.pem", "ca.pem")
+	app.ListenMutualTLSWithCertificate(":443", cert, "ca.pem")
+}
+`)
 
 	var buf bytes.Buffer
 	cmd := newCmd(&buf)
+	report := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(context.Background(), report))
 	require.NoError(t, v3.MigrateListenMethods(cmd, dir, nil, nil))
 
 	content := readFile(t, file)
-	assert.NotContains(t, content, "ListenTLS(")
-	assert.NotContains(t, content, "ListenTLSWithCertificate(")
-	assert.NotContains(t, content, "ListenMutualTLS(")
-	assert.NotContains(t, content, "ListenMutualTLSWithCertificate(")
-	assert.Equal(t, 4, strings.Count(content, ".Listen("))
+	assert.Contains(t, content, `app.Listen(":443", fiber.ListenConfig{CertFile: "cert.pem", CertKeyFile: "key.pem"})`)
+	assert.Contains(t, content, `app.Listen(":443", fiber.ListenConfig{CertFile: "cert.pem", CertKeyFile: "key.pem", CertClientFile: "ca.pem"})`)
+	// calls with a certificate are left for manual migration
+	assert.Contains(t, content, `app.ListenTLSWithCertificate(":443", cert)`)
+	assert.Contains(t, content, `app.ListenMutualTLSWithCertificate(":443", cert, "ca.pem")`)
 	assert.Contains(t, buf.String(), "Migrating listen methods")
+
+	findings := report.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, 13, findings[0].Line)
+	assert.Equal(t, "removed ListenTLSWithCertificate", findings[0].Message)
+	assert.Equal(t, "removed ListenMutualTLSWithCertificate", findings[1].Message)
+}
+
+func Test_MigrateListenMethods_Compiles(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+
+	// a stub of the fiber v3 listen API, the module proxy is not reachable
+	// from the tests
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "fiber")
+	require.NoError(t, os.MkdirAll(stub, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(stub, "go.mod"), []byte("module github.com/gofiber/fiber/v3\n\ngo 1.24\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(stub, "app.go"), []byte(`package fiber
+
+type App struct{}
+
+type ListenConfig struct {
+	CertFile       string
+	CertKeyFile    string
+	CertClientFile string
+}
+
+func New() *App { return &App{} }
+
+func (*App) Listen(_ string, _ ...ListenConfig) error { return nil }
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example
+
+go 1.24
+
+require github.com/gofiber/fiber/v3 v3.0.0
+
+replace github.com/gofiber/fiber/v3 => ./fiber
+`), 0o600))
+	writeTempFile(t, dir, `package main
+
+import app "github.com/gofiber/fiber/v3"
+
+func main() {
+	a := app.New()
+	_ = a.ListenTLS(":443", "cert.pem", "key.pem")
+	_ = a.ListenMutualTLS(":443", "cert.pem", "key.pem", "ca.pem")
+}
+`)
+
+	var buf bytes.Buffer
+	require.NoError(t, v3.MigrateListenMethods(newCmd(&buf), dir, nil, nil))
+
+	build := exec.Command("go", "build", "-o", os.DevNull, ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
 }
 
 func Test_MigrateFilesystemMiddleware(t *testing.T) {
@@ -576,7 +806,7 @@ func Test_MigrateFilesystemMiddleware(t *testing.T) {
 
 	file := writeTempFile(t, dir, `package main
 import (
-    "github.com/gofiber/fiber/v2/middleware/filesystem"
+    "github.com/gofiber/fiber/v3/middleware/filesystem"
     "net/http"
 )
 func main() {
@@ -591,6 +821,7 @@ func main() {
 	require.NoError(t, v3.MigrateFilesystemMiddleware(cmd, dir, nil, nil))
 
 	content := readFile(t, file)
+	assert.Contains(t, content, `"github.com/gofiber/fiber/v3/middleware/static"`)
 	assert.Contains(t, content, `static.New("", static.Config{`)
 	assert.Contains(t, content, `FS: os.DirFS("./assets")`)
 	assert.Contains(t, content, `IndexNames: []string{"index.html"}`)
@@ -778,3 +1009,175 @@ var _ = session.New(session.Config{
 	assert.NotContains(t, content, "Expiration:")
 	assert.Contains(t, buf.String(), "Migrating session middleware configs")
 }
+
+func Test_MigrateViewBind_OnlyCtxReceivers(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "mvbctx")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	file := writeTempFile(t, dir, `package main
+import "github.com/gofiber/fiber/v2"
+func handler(c fiber.Ctx) error {
+    // c.Bind(fiber.Map{}) in a comment stays
+    s := "c.Bind(x)"
+    _ = s
+    binder.Bind(fiber.Map{})
+    return c.Bind(fiber.Map{
+        "title": "home",
+    })
+}`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	require.NoError(t, v3.MigrateViewBind(cmd, dir, nil, nil))
+
+	content := readFile(t, file)
+	assert.Contains(t, content, "// c.Bind(fiber.Map{}) in a comment stays")
+	assert.Contains(t, content, `s := "c.Bind(x)"`)
+	assert.Contains(t, content, "binder.Bind(fiber.Map{})")
+	assert.Contains(t, content, "return c.ViewBind(fiber.Map{\n")
+}
+
+func Test_MigrateLimiterConfig_NestedBraces(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "mlimiternested")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	file := writeTempFile(t, dir, `package main
+import (
+    "github.com/gofiber/fiber/v2"
+    "github.com/gofiber/fiber/v2/middleware/limiter"
+    "time"
+)
+var cache = struct{ Key string }{Key: "keep"}
+var _ = limiter.New(limiter.Config{
+    Next: func(c fiber.Ctx) bool { return false },
+    Key: func(c fiber.Ctx) string { return c.IP() },
+    Duration: time.Minute,
+})`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	require.NoError(t, v3.MigrateLimiterConfig(cmd, dir, nil, nil))
+
+	content := readFile(t, file)
+	assert.Contains(t, content, `{Key: "keep"}`)
+	assert.Contains(t, content, "KeyGenerator: func(c fiber.Ctx) string")
+	assert.Contains(t, content, "Expiration: time.Minute")
+}
+
+func Test_MigrateCORSConfig_AliasedImport(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "mcorsalias")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	file := writeTempFile(t, dir, `package main
+import (
+    corsmw "github.com/gofiber/fiber/v2/middleware/cors"
+    "other/cors"
+)
+var origins = "https://a.com"
+var _ = cors.Config{AllowOrigins: "https://c.com"}
+var _ = corsmw.New(corsmw.Config{
+    AllowOrigins: "https://a.com, https://b.com", // trusted origins
+    AllowHeaders: origins,
+})`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	require.NoError(t, v3.MigrateCORSConfig(cmd, dir, nil, nil))
+
+	content := readFile(t, file)
+	assert.Contains(t, content, `cors.Config{AllowOrigins: "https://c.com"}`)
+	assert.Contains(t, content, `AllowOrigins: []string{"https://a.com", "https://b.com"}, // trusted origins`)
+	assert.Contains(t, content, "AllowHeaders: origins,")
+}
+
+func Test_MigrateStaticRoutes_AddsImport(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "msrimport")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	file := writeTempFile(t, dir, `package main
+import (
+    "github.com/gofiber/fiber/v3"
+)
+func main() {
+    app := fiber.New()
+    app.Static("/assets",
+        "./public",
+        fiber.Static{Compress: true},
+    )
+}`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	require.NoError(t, v3.MigrateStaticRoutes(cmd, dir, nil, nil))
+
+	content := readFile(t, file)
+	assert.Contains(t, content, `"github.com/gofiber/fiber/v3/middleware/static"`)
+	assert.Contains(t, content, `app.Get("/assets*", static.New("./public", static.Config{Compress: true}))`)
+}
+
+func Test_MigrateAppMethods_OnlyFiberReceivers(t *testing.T) {
+	t.Parallel()
+
+	dir, err := os.MkdirTemp("", "mappreceivers")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(dir)) }()
+
+	file := writeTempFile(t, dir, `package main
+import (
+    "net/http"
+    "sync"
+    "testing"
+
+    "github.com/gofiber/fiber/v2"
+)
+type server struct {
+    app *fiber.App
+}
+func register(r fiber.Router, sub *fiber.App) {
+    r.Mount("/sub", sub)
+}
+func main() {
+    var wg sync.WaitGroup
+    wg.Add(1, 2, 3)
+    mux := http.NewServeMux()
+    mux.Mount("/api", mux)
+    files.Static("/assets", "./public")
+    s := server{app: fiber.New()}
+    s.app.Add(fiber.MethodGet, "/s", nil)
+    api := s.app.Group("/api")
+    api.Add(fiber.MethodPost, "/api", nil)
+}
+func Test_App(t *testing.T) {
+    client.Test(req, 1000)
+    fiber.New().Test(req, 1000)
+}`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	require.NoError(t, v3.MigrateMount(cmd, dir, nil, nil))
+	require.NoError(t, v3.MigrateAddMethod(cmd, dir, nil, nil))
+	require.NoError(t, v3.MigrateStaticRoutes(cmd, dir, nil, nil))
+	require.NoError(t, v3.MigrateAppTestConfig(cmd, dir, nil, nil))
+
+	content := readFile(t, file)
+	assert.Contains(t, content, "wg.Add(1, 2, 3)")
+	assert.Contains(t, content, `mux.Mount("/api", mux)`)
+	assert.Contains(t, content, `files.Static("/assets", "./public")`)
+	assert.Contains(t, content, "client.Test(req, 1000)")
+	assert.Contains(t, content, `r.Use("/sub", sub)`)
+	assert.Contains(t, content, `s.app.Add([]string{fiber.MethodGet}, "/s", nil)`)
+	assert.Contains(t, content, `api.Add([]string{fiber.MethodPost}, "/api", nil)`)
+	assert.Contains(t, content, "fiber.New().Test(req, fiber.TestConfig{")
+}
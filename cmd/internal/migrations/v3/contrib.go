@@ -0,0 +1,232 @@
+package v3
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	modsemver "golang.org/x/mod/semver"
+
+	"github.com/gofiber/cli/cmd/internal"
+	"github.com/gofiber/cli/cmd/internal/migrations/rules"
+)
+
+// contribModule is an entry of the compatibility table of the contrib,
+// storage and template modules that have to move together with Fiber v3.
+type contribModule struct {
+	// Path is the module path used with Fiber v2 and NewPath the path of
+	// the release for Fiber v3. They are equal if only the version changes.
+	Path    string
+	NewPath string
+	// Version is the first release of NewPath that supports Fiber v3.
+	Version string
+	// Package and NewPackage are the package names if they changed, imports
+	// without a name keep the old one as alias.
+	Package    string
+	NewPackage string
+	// Rules are the API changes of the release, they apply to files
+	// importing NewPath.
+	Rules []rules.Rule
+}
+
+// nextToNew is the field change of the middlewares that renamed their Filter
+// option to Next like the core middlewares.
+var nextToNew = rules.FieldRule{Rename: map[string]string{"Filter": "Next"}}
+
+// contribModules is the compatibility table, see the release notes of the
+// gofiber/contrib, gofiber/storage and gofiber/template repositories.
+var contribModules = []contribModule{
+	{
+		Path: "github.com/gofiber/contrib/jwt", NewPath: "github.com/gofiber/contrib/v3/jwt", Version: "v1.0.0",
+		Rules: []rules.Rule{{
+			Import: "github.com/gofiber/contrib/v3/jwt",
+			Fields: map[string]rules.FieldRule{"Config": {
+				Rename: nextToNew.Rename,
+				Remove: map[string]string{
+					"TokenLookup": `Set Extractor, e.g. extractors.FromAuthHeader("Bearer")`,
+					"AuthScheme":  `Set Extractor, e.g. extractors.FromAuthHeader("Bearer")`,
+				},
+			}},
+		}},
+	},
+	{
+		Path: "github.com/gofiber/contrib/websocket", NewPath: "github.com/gofiber/contrib/v3/websocket", Version: "v1.0.0",
+		Rules: []rules.Rule{{
+			Import: "github.com/gofiber/contrib/v3/websocket",
+			Fields: map[string]rules.FieldRule{"Config": nextToNew},
+		}},
+	},
+	{
+		Path: "github.com/gofiber/contrib/fiberzap/v2", NewPath: "github.com/gofiber/contrib/v3/zap", Version: "v1.0.0",
+		Package: "fiberzap", NewPackage: "zap",
+	},
+	{
+		Path: "github.com/gofiber/contrib/fiberzerolog", NewPath: "github.com/gofiber/contrib/v3/zerolog", Version: "v1.0.0",
+		Package: "fiberzerolog", NewPackage: "zerolog",
+	},
+	{
+		Path: "github.com/gofiber/contrib/fibersentry", NewPath: "github.com/gofiber/contrib/v3/sentry", Version: "v1.0.0",
+		Package: "fibersentry", NewPackage: "sentry",
+	},
+	{
+		Path: "github.com/gofiber/contrib/fibernewrelic", NewPath: "github.com/gofiber/contrib/v3/newrelic", Version: "v1.0.0",
+		Package: "fibernewrelic", NewPackage: "newrelic",
+	},
+	{
+		Path: "github.com/gofiber/contrib/otelfiber/v2", NewPath: "github.com/gofiber/contrib/v3/otel", Version: "v1.0.0",
+		Package: "otelfiber", NewPackage: "otel",
+	},
+	{
+		Path: "github.com/gofiber/contrib/fiberi18n/v2", NewPath: "github.com/gofiber/contrib/v3/i18n", Version: "v1.0.0",
+		Package: "fiberi18n", NewPackage: "i18n",
+	},
+	{Path: "github.com/gofiber/contrib/casbin", NewPath: "github.com/gofiber/contrib/v3/casbin", Version: "v1.0.0"},
+	{Path: "github.com/gofiber/contrib/monitor", NewPath: "github.com/gofiber/contrib/v3/monitor", Version: "v1.0.0"},
+	{Path: "github.com/gofiber/contrib/paseto", NewPath: "github.com/gofiber/contrib/v3/paseto", Version: "v1.0.0"},
+	{Path: "github.com/gofiber/contrib/swagger", NewPath: "github.com/gofiber/contrib/v3/swagger", Version: "v1.0.0"},
+
+	{Path: "github.com/gofiber/storage/memory/v2", NewPath: "github.com/gofiber/storage/memory/v2", Version: "v2.1.0"},
+	{Path: "github.com/gofiber/storage/redis/v3", NewPath: "github.com/gofiber/storage/redis/v3", Version: "v3.4.0"},
+	{Path: "github.com/gofiber/storage/postgres/v3", NewPath: "github.com/gofiber/storage/postgres/v3", Version: "v3.2.0"},
+	{Path: "github.com/gofiber/storage/sqlite3/v2", NewPath: "github.com/gofiber/storage/sqlite3/v2", Version: "v2.2.0"},
+
+	{Path: "github.com/gofiber/template/html/v2", NewPath: "github.com/gofiber/template/html/v3", Version: "v3.0.0"},
+	{Path: "github.com/gofiber/template/django/v3", NewPath: "github.com/gofiber/template/django/v4", Version: "v4.0.0"},
+	{Path: "github.com/gofiber/template/handlebars/v2", NewPath: "github.com/gofiber/template/handlebars/v3", Version: "v3.0.0"},
+	{Path: "github.com/gofiber/template/pug/v2", NewPath: "github.com/gofiber/template/pug/v3", Version: "v3.0.0"},
+}
+
+// contribModuleOf returns the table entry of the module the import path
+// belongs to.
+func contribModuleOf(importPath string) (contribModule, bool) {
+	for _, m := range contribModules {
+		if importPath == m.Path || strings.HasPrefix(importPath, m.Path+"/") {
+			return m, true
+		}
+	}
+	return contribModule{}, false
+}
+
+// MigrateContribPackages moves the contrib, storage and template modules of
+// the compatibility table to their releases for Fiber v3. It rewrites the
+// requirements of go.mod and the imports and applies the API changes of the
+// releases.
+func MigrateContribPackages(cmd *cobra.Command, cwd string, _, _ *semver.Version) error {
+	err := internal.ChangeFileAST(cmd.Context(), cwd, func(f *internal.GoFile) {
+		for _, spec := range f.File.Imports {
+			importPath := internal.ImportPath(spec)
+			m, ok := contribModuleOf(importPath)
+			if !ok || m.NewPath == m.Path {
+				continue
+			}
+			newPath := m.NewPath + strings.TrimPrefix(importPath, m.Path)
+			text := strconv.Quote(newPath)
+			// keep the name the file refers to the package by
+			if spec.Name == nil && m.Package != m.NewPackage && importPath == m.Path {
+				text = m.Package + " " + text
+			}
+			f.Replace(spec.Path, text)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate contrib imports: %w", err)
+	}
+
+	for _, m := range contribModules {
+		for _, r := range m.Rules {
+			for _, process := range r.Processors() {
+				if err := internal.ChangeFileAST(cmd.Context(), cwd, process); err != nil {
+					return fmt.Errorf("failed to migrate %s: %w", m.NewPath, err)
+				}
+			}
+		}
+	}
+
+	if err := migrateContribRequirements(cmd, cwd); err != nil {
+		return err
+	}
+
+	cmd.Println("Migrating contrib, storage and template packages")
+	return nil
+}
+
+// migrateContribRequirements replaces the requirements of the table's
+// modules in go.mod by their releases for Fiber v3. Requirements of a newer
+// version are kept.
+func migrateContribRequirements(cmd *cobra.Command, cwd string) error {
+	modFile := filepath.Join(cwd, "go.mod")
+	b, err := internal.ReadFile(cmd.Context(), modFile)
+	if err != nil {
+		return err
+	}
+	mf, err := modfile.Parse(modFile, b, nil)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", modFile, err)
+	}
+
+	type move struct {
+		m        contribModule
+		indirect bool
+	}
+	var moved []move
+	for _, r := range mf.Require {
+		m, ok := contribModuleOf(r.Mod.Path)
+		if !ok || r.Mod.Path != m.Path {
+			continue
+		}
+		if m.NewPath != m.Path {
+			moved = append(moved, move{m, r.Indirect})
+			continue
+		}
+		if modsemver.Compare(r.Mod.Version, m.Version) < 0 {
+			if err := mf.AddRequire(m.Path, m.Version); err != nil {
+				return fmt.Errorf("require %s: %w", m.Path, err)
+			}
+		}
+	}
+	for _, mv := range moved {
+		if err := mf.DropRequire(mv.m.Path); err != nil {
+			return fmt.Errorf("drop %s: %w", mv.m.Path, err)
+		}
+		mf.AddNewRequire(mv.m.NewPath, mv.m.Version, mv.indirect)
+	}
+	mf.SortBlocks()
+
+	mf.Cleanup()
+	out := modfile.Format(mf.Syntax)
+	if bytes.Equal(out, b) {
+		return nil
+	}
+	return internal.WriteFile(cmd.Context(), modFile, out) //nolint:wrapcheck // names the file
+}
+
+// requireContribModule adds the release of m for Fiber v3 to the
+// requirements of go.mod unless a newer version is required already.
+func requireContribModule(cmd *cobra.Command, cwd string, m contribModule) error {
+	modFile := filepath.Join(cwd, "go.mod")
+	b, err := internal.ReadFile(cmd.Context(), modFile)
+	if err != nil {
+		return err
+	}
+	mf, err := modfile.Parse(modFile, b, nil)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", modFile, err)
+	}
+
+	for _, r := range mf.Require {
+		if r.Mod.Path == m.NewPath && modsemver.Compare(r.Mod.Version, m.Version) >= 0 {
+			return nil
+		}
+	}
+	if err := mf.AddRequire(m.NewPath, m.Version); err != nil {
+		return fmt.Errorf("require %s: %w", m.NewPath, err)
+	}
+
+	mf.Cleanup()
+	return internal.WriteFile(cmd.Context(), modFile, modfile.Format(mf.Syntax)) //nolint:wrapcheck // names the file
+}
@@ -0,0 +1,98 @@
+package v3_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal"
+	v3 "github.com/gofiber/cli/cmd/internal/migrations/v3" //nolint:revive // alias required
+)
+
+func Test_MigrateContribPackages(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example
+
+go 1.24
+
+require (
+	github.com/gofiber/contrib/fiberzap/v2 v2.1.4
+	github.com/gofiber/contrib/jwt v1.0.10
+	github.com/gofiber/fiber/v3 v3.0.0
+	github.com/gofiber/storage/redis/v3 v3.1.2
+	github.com/gofiber/storage/sqlite3/v2 v2.3.0
+	github.com/gofiber/template/html/v2 v2.1.1 // indirect
+)
+`), 0o600))
+	file := writeTempFile(t, dir, `package main
+
+import (
+	"github.com/gofiber/contrib/fiberzap/v2"
+	jwtware "github.com/gofiber/contrib/jwt"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/storage/redis/v3"
+	"github.com/gofiber/template/html/v2"
+)
+
+func main() {
+	app := fiber.New(fiber.Config{Views: html.New("./views", ".html")})
+	app.Use(fiberzap.New())
+	app.Use(jwtware.New(jwtware.Config{
+		Filter:      func(c fiber.Ctx) bool { return false },
+		TokenLookup: "header:Authorization",
+	}))
+	_ = redis.New()
+}
+`)
+
+	var buf bytes.Buffer
+	cmd := newCmd(&buf)
+	report := internal.NewReport(false)
+	cmd.SetContext(internal.WithReport(context.Background(), report))
+	require.NoError(t, v3.MigrateContribPackages(cmd, dir, nil, nil))
+
+	assert.Equal(t, `package main
+
+import (
+	fiberzap "github.com/gofiber/contrib/v3/zap"
+	jwtware "github.com/gofiber/contrib/v3/jwt"
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/storage/redis/v3"
+	"github.com/gofiber/template/html/v3"
+)
+
+func main() {
+	app := fiber.New(fiber.Config{Views: html.New("./views", ".html")})
+	app.Use(fiberzap.New())
+	app.Use(jwtware.New(jwtware.Config{
+		Next:      func(c fiber.Ctx) bool { return false },
+	}))
+	_ = redis.New()
+}
+`, readFile(t, file))
+	assert.Equal(t, `module example
+
+go 1.24
+
+require (
+	github.com/gofiber/contrib/v3/jwt v1.0.0
+	github.com/gofiber/contrib/v3/zap v1.0.0
+	github.com/gofiber/fiber/v3 v3.0.0
+	github.com/gofiber/storage/redis/v3 v3.4.0
+	github.com/gofiber/storage/sqlite3/v2 v2.3.0
+	github.com/gofiber/template/html/v3 v3.0.0 // indirect
+)
+`, readFile(t, filepath.Join(dir, "go.mod")))
+
+	findings := report.Findings()
+	require.Len(t, findings, 1)
+	assert.Equal(t, "removed jwtware.Config.TokenLookup", findings[0].Message)
+	assert.Contains(t, buf.String(), "Migrating contrib, storage and template packages")
+}
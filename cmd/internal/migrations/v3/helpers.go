@@ -0,0 +1,313 @@
+package v3
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+var (
+	fiberPathRegexp      = regexp.MustCompile(`^github\.com/gofiber/fiber(/v\d+)?$`)
+	middlewarePathRegexp = regexp.MustCompile(`^github\.com/gofiber/fiber(/v\d+)?/middleware/([^/]+)$`)
+)
+
+// fiberImport returns the name and path of the fiber import of the file.
+func fiberImport(f *internal.GoFile) (name, importPath string, ok bool) {
+	spec := f.FindImport(fiberPathRegexp.MatchString)
+	if spec == nil {
+		return "", "", false
+	}
+	name = internal.ImportName(spec)
+	if name == "_" || name == "." {
+		return "", "", false
+	}
+	return name, internal.ImportPath(spec), true
+}
+
+// middlewareImport returns the name under which the fiber middleware package
+// mw is imported.
+func middlewareImport(f *internal.GoFile, mw string) (string, bool) {
+	spec := f.FindImport(func(p string) bool {
+		m := middlewarePathRegexp.FindStringSubmatch(p)
+		return m != nil && m[2] == mw
+	})
+	if spec == nil {
+		return "", false
+	}
+	name := internal.ImportName(spec)
+	if name == "_" || name == "." {
+		return "", false
+	}
+	return name, true
+}
+
+// middlewarePath returns the import path of the middleware package mw that
+// belongs to the given fiber import path.
+func middlewarePath(fiberPath, mw string) string {
+	return path.Join(fiberPath, "middleware", mw)
+}
+
+// isSelector reports whether expr is pkg.name.
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg && sel.Sel.Name == name
+}
+
+// isCtxType reports whether expr is fiber.Ctx or *fiber.Ctx.
+func isCtxType(expr ast.Expr, fiberName string) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	return isSelector(expr, fiberName, "Ctx")
+}
+
+// forEachConfig calls fn for every composite literal of type pkg.<typeName>.
+func forEachConfig(f *internal.GoFile, pkg string, fn func(lit *ast.CompositeLit), typeNames ...string) {
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		for _, typeName := range typeNames {
+			if isSelector(lit.Type, pkg, typeName) {
+				fn(lit)
+				break
+			}
+		}
+		return true
+	})
+}
+
+// fields returns the keyed elements of a composite literal by key name.
+func fields(lit *ast.CompositeLit) map[string]*ast.KeyValueExpr {
+	m := make(map[string]*ast.KeyValueExpr, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok {
+			m[key.Name] = kv
+		}
+	}
+	return m
+}
+
+// renameFields renames keys of the composite literal according to renames.
+func renameFields(f *internal.GoFile, lit *ast.CompositeLit, renames map[string]string) {
+	for name, kv := range fields(lit) {
+		if to, ok := renames[name]; ok {
+			f.Replace(kv.Key, to)
+		}
+	}
+}
+
+// removeFields drops the given keys from the composite literal and reports
+// each removal together with the manual follow-up in removed.
+func removeFields(f *internal.GoFile, lit *ast.CompositeLit, removed map[string]string) {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if action, ok := removed[key.Name]; ok {
+			f.Delete(kv)
+			f.Report(kv, fmt.Sprintf("removed %s.%s", f.Source(lit.Type), key.Name), action)
+		}
+	}
+}
+
+// forEachMethodCall calls fn for every call of the form x.Method(...).
+func forEachMethodCall(f *internal.GoFile, fn func(call *ast.CallExpr, sel *ast.SelectorExpr)) {
+	ast.Inspect(f.File, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			fn(call, sel)
+		}
+		return true
+	})
+}
+
+// routerMethods are the methods of fiber.App, fiber.Group and fiber.Router
+// that return a router again.
+var routerMethods = map[string]bool{
+	"Group": true, "Route": true, "Use": true, "Name": true, "Mount": true, "Static": true, "Add": true, "All": true,
+	"Get": true, "Head": true, "Post": true, "Put": true, "Delete": true, "Connect": true, "Options": true, "Trace": true, "Patch": true,
+}
+
+// forEachAppCall calls fn for every method call whose receiver is a Fiber
+// app, group or router. Without type information the receivers are resolved
+// by name within the file: variables and struct fields declared as
+// *fiber.App, *fiber.Group or fiber.Router, variables assigned the result of
+// fiber.New and routers derived from them, e.g. by app.Group.
+func forEachAppCall(f *internal.GoFile, fn func(call *ast.CallExpr, sel *ast.SelectorExpr)) {
+	fiberName, _, ok := fiberImport(f)
+	if !ok {
+		return
+	}
+	r := &appResolver{fiberName: fiberName, names: map[string]bool{}, fields: map[string]bool{}}
+	r.collect(f.File)
+
+	forEachMethodCall(f, func(call *ast.CallExpr, sel *ast.SelectorExpr) {
+		if r.isApp(sel.X) {
+			fn(call, sel)
+		}
+	})
+}
+
+type appResolver struct {
+	names     map[string]bool // variables and parameters
+	fields    map[string]bool // struct fields
+	fiberName string
+}
+
+// collect records the app variables of the file. Assignments are visited
+// until no new name is found, so routers derived from routers declared later
+// in the file are resolved as well.
+func (r *appResolver) collect(file *ast.File) {
+	for changed := true; changed; {
+		seen := len(r.names) + len(r.fields)
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				if r.isAppType(n.Type) {
+					for _, id := range n.Names {
+						r.names[id.Name] = true
+						r.fields[id.Name] = true
+					}
+				}
+			case *ast.ValueSpec:
+				for i, id := range n.Names {
+					if (n.Type != nil && r.isAppType(n.Type)) || (i < len(n.Values) && r.isApp(n.Values[i])) {
+						r.names[id.Name] = true
+					}
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					if !r.isApp(n.Rhs[i]) {
+						continue
+					}
+					switch lhs := lhs.(type) {
+					case *ast.Ident:
+						r.names[lhs.Name] = true
+					case *ast.SelectorExpr:
+						r.fields[lhs.Sel.Name] = true
+					}
+				}
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok && r.isApp(n.Value) {
+					r.fields[key.Name] = true
+				}
+			}
+			return true
+		})
+		changed = len(r.names)+len(r.fields) != seen
+	}
+}
+
+// isAppType reports whether expr is *fiber.App, *fiber.Group or fiber.Router.
+func (r *appResolver) isAppType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return isSelector(star.X, r.fiberName, "App") || isSelector(star.X, r.fiberName, "Group")
+	}
+	return isSelector(expr, r.fiberName, "Router")
+}
+
+// isApp reports whether expr refers to a Fiber app, group or router.
+func (r *appResolver) isApp(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return r.names[e.Name]
+	case *ast.ParenExpr:
+		return r.isApp(e.X)
+	case *ast.StarExpr:
+		return r.isApp(e.X)
+	case *ast.UnaryExpr:
+		return e.Op == token.AND && r.isApp(e.X)
+	case *ast.SelectorExpr:
+		return r.fields[e.Sel.Name]
+	case *ast.CallExpr:
+		sel, ok := e.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		if isSelector(sel, r.fiberName, "New") {
+			return true
+		}
+		return routerMethods[sel.Sel.Name] && r.isApp(sel.X)
+	}
+	return false
+}
+
+// forEachCtxCall calls fn for every method call whose receiver is an
+// identifier declared as fiber.Ctx or *fiber.Ctx in the signature of an
+// enclosing function.
+func forEachCtxCall(f *internal.GoFile, fn func(call *ast.CallExpr, sel *ast.SelectorExpr)) {
+	fiberName, _, ok := fiberImport(f)
+	if !ok {
+		return
+	}
+	ast.Walk(&ctxScope{fiberName: fiberName, fn: fn}, f.File)
+}
+
+// ctxScope tracks the identifiers that refer to a fiber.Ctx while walking the
+// AST. Every function introduces a new scope for its parameters.
+type ctxScope struct {
+	names     map[string]bool
+	fn        func(call *ast.CallExpr, sel *ast.SelectorExpr)
+	fiberName string
+}
+
+func (s *ctxScope) Visit(n ast.Node) ast.Visitor {
+	var ft *ast.FuncType
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		ft = n.Type
+	case *ast.FuncLit:
+		ft = n.Type
+	case *ast.CallExpr:
+		if sel, ok := n.Fun.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && s.names[id.Name] {
+				s.fn(n, sel)
+			}
+		}
+	}
+	if ft == nil || ft.Params == nil {
+		return s
+	}
+
+	names := make(map[string]bool, len(s.names))
+	for name := range s.names {
+		names[name] = true
+	}
+	for _, field := range ft.Params.List {
+		isCtx := isCtxType(field.Type, s.fiberName)
+		for _, id := range field.Names {
+			if isCtx {
+				names[id.Name] = true
+			} else {
+				delete(names, id.Name)
+			}
+		}
+	}
+
+	return &ctxScope{names: names, fn: s.fn, fiberName: s.fiberName}
+}
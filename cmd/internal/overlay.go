@@ -0,0 +1,414 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Overlay is an in-memory layer on top of the file system. Reads fall through
+// to disk until a file has been written, writes are only kept in memory until
+// Commit is called. It records which migration step changed which file and
+// keeps the original content so a committed change can be rolled back.
+type Overlay struct {
+	files   map[string]*overlayFile
+	dirs    map[string]string
+	touched map[string][]string
+	stage   string
+	stages  []string
+	mu      sync.Mutex
+}
+
+type overlayFile struct {
+	orig     []byte
+	content  []byte
+	versions []Step
+	existed  bool
+}
+
+// Step is the content of a file after a migration step changed it.
+type Step struct {
+	Stage   string
+	Content []byte
+}
+
+// NewOverlay returns an empty Overlay.
+func NewOverlay() *Overlay {
+	return &Overlay{
+		files:   make(map[string]*overlayFile),
+		dirs:    make(map[string]string),
+		touched: make(map[string][]string),
+	}
+}
+
+type overlayKey struct{}
+
+// WithOverlay returns a copy of ctx that carries the overlay. File operations
+// of this package performed with the returned context go through o.
+func WithOverlay(ctx context.Context, o *Overlay) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, overlayKey{}, o)
+}
+
+// OverlayFromContext returns the overlay carried by ctx or nil.
+func OverlayFromContext(ctx context.Context) *Overlay {
+	if ctx == nil {
+		return nil
+	}
+	o, _ := ctx.Value(overlayKey{}).(*Overlay) //nolint:errcheck // nil if absent
+	return o
+}
+
+// ReadFile reads the file from the overlay carried by ctx or from disk.
+func ReadFile(ctx context.Context, path string) ([]byte, error) {
+	if o := OverlayFromContext(ctx); o != nil {
+		return o.ReadFile(path)
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// WriteFile writes the file to the overlay carried by ctx or to disk.
+func WriteFile(ctx context.Context, path string, data []byte) error {
+	if o := OverlayFromContext(ctx); o != nil {
+		return o.WriteFile(path, data)
+	}
+	if err := os.WriteFile(filepath.Clean(path), data, 0o600); err != nil {
+		return fmt.Errorf("write file %s: %w", path, err)
+	}
+	return nil
+}
+
+// SetStage sets the name of the migration step that is responsible for the
+// following writes.
+func (o *Overlay) SetStage(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stage = name
+	o.declare(name)
+}
+
+// ReadFile returns the current content of the file.
+func (o *Overlay) ReadFile(path string) ([]byte, error) {
+	path = filepath.Clean(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if f, ok := o.files[path]; ok {
+		return f.content, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// WriteFile stores data as the new content of the file.
+func (o *Overlay) WriteFile(path string, data []byte) error {
+	o.mu.Lock()
+	stage := o.stage
+	o.mu.Unlock()
+
+	return o.WriteFileSteps(path, Step{Stage: stage, Content: data})
+}
+
+// WriteFileSteps stores the content of the file after each of the given
+// migration steps instead of attributing the change to the current stage. It
+// is used when the steps did not run one after another. The content of the
+// last step becomes the new content of the file.
+func (o *Overlay) WriteFileSteps(path string, steps ...Step) error {
+	path = filepath.Clean(path)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	f, err := o.track(path)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		if bytes.Equal(f.content, step.Content) {
+			continue
+		}
+		o.touch(step.Stage, path)
+
+		content := append([]byte(nil), step.Content...)
+		if n := len(f.versions); n > 0 && f.versions[n-1].Stage == step.Stage {
+			f.versions[n-1].Content = content
+		} else {
+			f.versions = append(f.versions, Step{Stage: step.Stage, Content: content})
+		}
+		f.content = content
+	}
+
+	return nil
+}
+
+func (o *Overlay) track(path string) (*overlayFile, error) {
+	if f, ok := o.files[path]; ok {
+		return f, nil
+	}
+
+	orig, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read file %s: %w", path, err)
+	}
+	f := &overlayFile{orig: orig, content: orig, existed: err == nil}
+	o.files[path] = f
+
+	return f, nil
+}
+
+// Track records the current content of the file on disk, so that Rollback
+// restores it even if it is changed outside of the overlay.
+func (o *Overlay) Track(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := o.track(filepath.Clean(path))
+	return err
+}
+
+// TrackDir copies the directory to a temporary backup, so that Rollback
+// restores it even if it is changed outside of the overlay. Missing
+// directories are ignored.
+func (o *Overlay) TrackDir(dir string) error {
+	dir = filepath.Clean(dir)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.dirs[dir]; ok {
+		return nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	backup, err := os.MkdirTemp("", "fiber-backup-")
+	if err != nil {
+		return fmt.Errorf("create backup dir: %w", err)
+	}
+	if err := copyDir(dir, backup); err != nil {
+		return fmt.Errorf("backup %s: %w", dir, err)
+	}
+	o.dirs[dir] = backup
+
+	return nil
+}
+
+// CommitStage writes the files changed by the migration step to disk with
+// the content they had after the step and returns their sorted paths.
+// Committing the steps in the order of Stages reproduces every intermediate
+// state of the migration.
+func (o *Overlay) CommitStage(stage string) ([]string, error) {
+	paths := o.Touched(stage)
+	for _, path := range paths {
+		o.mu.Lock()
+		var content []byte
+		for _, v := range o.files[path].versions {
+			if v.Stage == stage {
+				content = v.Content
+			}
+		}
+		o.mu.Unlock()
+
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return nil, fmt.Errorf("write file %s: %w", path, err)
+		}
+	}
+	return paths, nil
+}
+
+// Commit writes every changed file to disk.
+func (o *Overlay) Commit() error {
+	for _, path := range o.Changed() {
+		o.mu.Lock()
+		content := o.files[path].content
+		o.mu.Unlock()
+
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return fmt.Errorf("write file %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Rollback restores every tracked file and directory to the state it had
+// before it was first written or tracked. Files that did not exist are removed.
+func (o *Overlay) Rollback() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for path, f := range o.files {
+		if !f.existed {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, f.orig, 0o600); err != nil {
+			return fmt.Errorf("restore %s: %w", path, err)
+		}
+	}
+
+	for dir, backup := range o.dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("remove %s: %w", dir, err)
+		}
+		if err := copyDir(backup, dir); err != nil {
+			return fmt.Errorf("restore %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// Close removes the directory backups created by TrackDir.
+func (o *Overlay) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for dir, backup := range o.dirs {
+		if err := os.RemoveAll(backup); err != nil {
+			return fmt.Errorf("remove backup of %s: %w", dir, err)
+		}
+		delete(o.dirs, dir)
+	}
+	return nil
+}
+
+// copyDir copies the directory tree src to dst. Symbolic links are copied as
+// links.
+func copyDir(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("rel path: %w", err)
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("mkdir %s: %w", target, err)
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("read link %s: %w", path, err)
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("create link %s: %w", target, err)
+			}
+		default:
+			b, err := os.ReadFile(path) // #nosec G304
+			if err != nil {
+				return fmt.Errorf("read file %s: %w", path, err)
+			}
+			if err := os.WriteFile(target, b, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("write file %s: %w", target, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("copy %s: %w", src, err)
+	}
+	return nil
+}
+
+func (o *Overlay) declare(stage string) {
+	for _, s := range o.stages {
+		if s == stage {
+			return
+		}
+	}
+	o.stages = append(o.stages, stage)
+}
+
+func (o *Overlay) touch(stage, path string) {
+	o.declare(stage)
+	paths := o.touched[stage]
+	for _, p := range paths {
+		if p == path {
+			return
+		}
+	}
+	o.touched[stage] = append(paths, path)
+}
+
+// Stages returns the migration steps that changed files in the order they
+// were run.
+func (o *Overlay) Stages() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var stages []string
+	for _, s := range o.stages {
+		if len(o.touched[s]) > 0 {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+// Changed returns the sorted paths whose content differs from disk.
+func (o *Overlay) Changed() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var paths []string
+	for path, f := range o.files {
+		if !bytes.Equal(f.orig, f.content) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Touched returns the files written by the given migration step.
+func (o *Overlay) Touched(stage string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	paths := append([]string(nil), o.touched[stage]...)
+	sort.Strings(paths)
+	return paths
+}
+
+// Diff returns a unified diff of the file between disk and overlay. name is
+// used in the diff header.
+func (o *Overlay) Diff(path, name string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	f, ok := o.files[filepath.Clean(path)]
+	if !ok {
+		return ""
+	}
+	return UnifiedDiff(name, f.orig, f.content)
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Overlay(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o600))
+
+	o := NewOverlay()
+	ctx := WithOverlay(context.Background(), o)
+	at.Same(o, OverlayFromContext(ctx))
+	at.Nil(OverlayFromContext(context.Background()))
+
+	o.SetStage("first")
+	require.NoError(t, WriteFile(ctx, path, []byte("package main\n\nfunc main() {}\n")))
+	o.SetStage("second")
+	require.NoError(t, WriteFile(ctx, path, []byte("package main\n\nfunc main() {}\n")))
+	o.SetStage("third")
+	require.NoError(t, WriteFile(ctx, filepath.Join(dir, "other.go"), []byte("package main\n")))
+
+	b, err := ReadFile(ctx, path)
+	require.NoError(t, err)
+	at.Equal("package main\n\nfunc main() {}\n", string(b))
+
+	// disk is untouched
+	b, err = os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package main\n", string(b))
+	at.NoFileExists(filepath.Join(dir, "other.go"))
+
+	at.Equal([]string{path, filepath.Join(dir, "other.go")}, o.Changed())
+	at.Equal([]string{"first", "third"}, o.Stages())
+	at.Equal([]string{path}, o.Touched("first"))
+	at.Empty(o.Touched("second"))
+	at.Contains(o.Diff(path, "main.go"), "+func main() {}")
+}
+
+func Test_Overlay_CommitRollback(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "other.go")
+	sum := filepath.Join(dir, "go.sum")
+	vendor := filepath.Join(dir, "vendor")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o600))
+	require.NoError(t, os.WriteFile(sum, []byte("sum\n"), 0o600))
+	require.NoError(t, os.Mkdir(vendor, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(vendor, "modules.txt"), []byte("old\n"), 0o600))
+
+	o := NewOverlay()
+	require.NoError(t, o.WriteFile(path, []byte("package app\n")))
+	require.NoError(t, o.WriteFile(other, []byte("package app\n")))
+	require.NoError(t, o.Commit())
+
+	b, err := os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package app\n", string(b))
+	at.FileExists(other)
+
+	// changes made outside of the overlay
+	require.NoError(t, o.Track(sum))
+	require.NoError(t, o.TrackDir(vendor))
+	require.NoError(t, o.TrackDir(filepath.Join(dir, "missing")))
+	require.NoError(t, os.WriteFile(sum, []byte("new\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(vendor, "modules.txt"), []byte("new\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(vendor, "extra.txt"), []byte("new\n"), 0o600))
+
+	require.NoError(t, o.Rollback())
+	require.NoError(t, o.Close())
+
+	b, err = os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package main\n", string(b))
+	at.NoFileExists(other)
+	b, err = os.ReadFile(sum) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("sum\n", string(b))
+	b, err = os.ReadFile(filepath.Join(vendor, "modules.txt")) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("old\n", string(b))
+	at.NoFileExists(filepath.Join(vendor, "extra.txt"))
+	at.NoDirExists(filepath.Join(dir, "missing"))
+}
+
+func Test_Overlay_CommitStage(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	other := filepath.Join(dir, "other.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o600))
+
+	o := NewOverlay()
+	o.SetStage("first")
+	o.SetStage("second")
+	o.SetStage("third")
+	require.NoError(t, o.WriteFileSteps(path,
+		Step{Stage: "first", Content: []byte("package app\n")},
+		Step{Stage: "second", Content: []byte("package app\n")},
+		Step{Stage: "third", Content: []byte("package web\n")},
+	))
+	o.SetStage("second")
+	require.NoError(t, o.WriteFile(other, []byte("package web\n")))
+
+	at.Equal([]string{"first", "second", "third"}, o.Stages())
+	at.Equal([]string{other}, o.Touched("second"))
+
+	paths, err := o.CommitStage("first")
+	require.NoError(t, err)
+	at.Equal([]string{path}, paths)
+	b, err := os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package app\n", string(b))
+	at.NoFileExists(other)
+
+	paths, err = o.CommitStage("second")
+	require.NoError(t, err)
+	at.Equal([]string{other}, paths)
+	at.FileExists(other)
+
+	_, err = o.CommitStage("third")
+	require.NoError(t, err)
+	b, err = os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package web\n", string(b))
+
+	require.NoError(t, o.Rollback())
+	b, err = os.ReadFile(path) // #nosec G304
+	require.NoError(t, err)
+	at.Equal("package main\n", string(b))
+	at.NoFileExists(other)
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Pipeline collects the file transforms of the migration steps of a module
+// and applies them in a single pass. Every Go file is loaded once, all
+// transforms run on it in memory in the order they were added and only files
+// whose content changed are written back. Files are processed concurrently.
+type Pipeline struct {
+	root       string
+	stage      string
+	transforms []transform
+	workers    int
+	mu         sync.Mutex
+}
+
+type transform struct {
+	text  FileProcessor
+	ast   ASTProcessor
+	stage string
+}
+
+// NewPipeline returns an empty Pipeline for the module in root that
+// processes up to workers files at once. GOMAXPROCS is used if workers is
+// not positive.
+func NewPipeline(root string, workers int) *Pipeline {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Pipeline{root: root, workers: workers}
+}
+
+type pipelineKey struct{}
+
+// WithPipeline returns a copy of ctx that carries the pipeline. ChangeFileAST
+// and ChangeFileContent called with the returned context add their processor
+// to p instead of walking the files.
+func WithPipeline(ctx context.Context, p *Pipeline) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, pipelineKey{}, p)
+}
+
+// PipelineFromContext returns the pipeline carried by ctx or nil.
+func PipelineFromContext(ctx context.Context) *Pipeline {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(pipelineKey{}).(*Pipeline) //nolint:errcheck // nil if absent
+	return p
+}
+
+// SetStage sets the name of the migration step the following transforms
+// belong to.
+func (p *Pipeline) SetStage(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stage = name
+}
+
+// Len returns the number of transforms waiting to be run.
+func (p *Pipeline) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.transforms)
+}
+
+// add queues the transform if cwd is the root of the pipeline.
+func (p *Pipeline) add(cwd string, t transform) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cwd != p.root {
+		return false
+	}
+	t.stage = p.stage
+	p.transforms = append(p.transforms, t)
+	return true
+}
+
+// Run applies the queued transforms to the Go files of the module and clears
+// the queue. Changes are attributed to the migration steps that made them
+// if ctx carries an Overlay.
+func (p *Pipeline) Run(ctx context.Context) error {
+	p.mu.Lock()
+	transforms := p.transforms
+	p.transforms = nil
+	p.mu.Unlock()
+
+	if len(transforms) == 0 {
+		return nil
+	}
+
+	paths, err := goFiles(ctx, p.root)
+	if err != nil {
+		return err
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.workers)
+	for _, path := range paths {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err //nolint:wrapcheck // another file failed already
+			}
+			return p.process(ctx, path, transforms)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("migrate %s: %w", p.root, err)
+	}
+	return nil
+}
+
+func (p *Pipeline) process(ctx context.Context, path string, transforms []transform) error {
+	content, ok, err := loadGoFile(ctx, path, transforms[0].stage)
+	if err != nil || !ok {
+		return err
+	}
+
+	steps, err := applyTransforms(ctx, path, content, transforms)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	if o := OverlayFromContext(ctx); o != nil {
+		return o.WriteFileSteps(path, steps...)
+	}
+	return WriteFile(ctx, path, steps[len(steps)-1].Content)
+}
+
+// applyTransforms runs the transforms on the content one after another and
+// returns the content after every stage that changed it. The file is only
+// parsed again after a transform changed it.
+func applyTransforms(ctx context.Context, path string, content []byte, transforms []transform) ([]Step, error) {
+	r := ReportFromContext(ctx)
+
+	var (
+		steps   []Step
+		f       *GoFile
+		invalid bool
+	)
+	for _, t := range transforms {
+		var out []byte
+		switch {
+		case t.text != nil:
+			out = []byte(t.text(string(content)))
+		case invalid:
+			// not valid Go, nothing to migrate
+			continue
+		default:
+			if f == nil {
+				var err error
+				if f, err = ParseGoFile(path, content); err != nil {
+					invalid = true
+					continue
+				}
+			}
+
+			t.ast(f)
+			if r != nil && r.TODOs() {
+				f.InsertTODOs()
+			}
+
+			var err error
+			if out, err = f.Apply(); err != nil {
+				return nil, fmt.Errorf("%s: apply edits to %s: %w", t.stage, path, err)
+			}
+			findings := f.Findings()
+			if r != nil {
+				for i := range findings {
+					findings[i].Migration = t.stage
+				}
+				r.Add(findings...)
+			}
+			if f.Changed() || len(findings) > 0 {
+				f = nil
+			}
+		}
+
+		if !bytes.Equal(out, content) {
+			content = out
+			f = nil
+			invalid = false
+			if n := len(steps); n > 0 && steps[n-1].Stage == t.stage {
+				steps[n-1].Content = out
+			} else {
+				steps = append(steps, Step{Stage: t.stage, Content: out})
+			}
+		}
+	}
+
+	return steps, nil
+}
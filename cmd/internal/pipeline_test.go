@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	at := assert.New(t)
+
+	dir := t.TempDir()
+	for i := range 20 {
+		src := fmt.Sprintf("package main\n\nfunc f%d() { oldName() }\n", i)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), []byte(src), 0o600))
+	}
+	untouched := filepath.Join(dir, "other.go")
+	require.NoError(t, os.WriteFile(untouched, []byte("package main\n"), 0o600))
+
+	o := NewOverlay()
+	r := NewReport(false)
+	p := NewPipeline(dir, 4)
+	ctx := WithPipeline(WithReport(WithOverlay(context.Background(), o), r), p)
+	at.Same(p, PipelineFromContext(ctx))
+	at.Nil(PipelineFromContext(context.Background()))
+
+	o.SetStage("rename")
+	p.SetStage("rename")
+	require.NoError(t, ChangeFileAST(ctx, dir, func(f *GoFile) {
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == "oldName" {
+				f.Replace(id, "newName")
+			}
+			return true
+		})
+	}))
+	o.SetStage("report")
+	p.SetStage("report")
+	require.NoError(t, ChangeFileAST(ctx, dir, func(f *GoFile) {
+		// sees the result of the previous transform
+		ast.Inspect(f.File, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == "newName" {
+				f.Report(id, "renamed call", "Check it")
+			}
+			return true
+		})
+	}))
+	o.SetStage("suffix")
+	p.SetStage("suffix")
+	require.NoError(t, ChangeFileContent(ctx, dir, func(content string) string {
+		return strings.ReplaceAll(content, "newName()", "newName(nil)")
+	}))
+
+	// transforms of another module are not queued
+	require.NoError(t, ChangeFileContent(ctx, t.TempDir(), func(content string) string { return content }))
+	at.Equal(3, p.Len())
+
+	// nothing is written before the pipeline runs
+	b, err := ReadFile(ctx, filepath.Join(dir, "f0.go"))
+	require.NoError(t, err)
+	at.Contains(string(b), "oldName()")
+
+	require.NoError(t, p.Run(ctx))
+	at.Zero(p.Len())
+
+	b, err = ReadFile(ctx, filepath.Join(dir, "f7.go"))
+	require.NoError(t, err)
+	at.Equal("package main\n\nfunc f7() { newName(nil) }\n", string(b))
+
+	at.Len(o.Changed(), 20)
+	at.NotContains(o.Changed(), untouched)
+	at.Equal([]string{"rename", "suffix"}, o.Stages())
+	at.Len(o.Touched("rename"), 20)
+
+	findings := r.Findings()
+	require.Len(t, findings, 20)
+	at.Equal(filepath.Join(dir, "f0.go"), findings[0].File)
+	at.Equal("report", findings[0].Migration)
+	at.Equal(3, findings[0].Line)
+}
+
+func Test_Pipeline_Error(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o600))
+
+	p := NewPipeline(dir, 0)
+	ctx := WithPipeline(context.Background(), p)
+	p.SetStage("overlap")
+	require.NoError(t, ChangeFileAST(ctx, dir, func(f *GoFile) {
+		f.Replace(f.File.Name, "a")
+		f.ReplaceRange(f.File.Package, f.File.Name.End(), "package b")
+	}))
+
+	err := p.Run(ctx)
+	require.ErrorContains(t, err, "overlap: apply edits to")
+
+	b, err := os.ReadFile(filepath.Join(dir, "main.go")) // #nosec G304
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(b))
+}
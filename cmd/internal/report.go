@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Finding is a change made by a migration that needs manual follow-up.
+type Finding struct {
+	File      string
+	Migration string
+	Message   string
+	Action    string
+	Line      int
+}
+
+// MigrationResult records whether a migration of the migrations list was
+// applied and which of its functions ran or were skipped by the selection.
+type MigrationResult struct {
+	Module string
+	From   string
+	To     string
+	// Hop is the hop of a migration across several major versions the
+	// result belongs to, e.g. "v1.14.6 to v2.0.0".
+	Hop       string
+	Functions []string
+	Skipped   []string
+	Applied   bool
+}
+
+// Report collects the results and findings of the migration steps.
+type Report struct {
+	stage      string
+	findings   []Finding
+	migrations []MigrationResult
+	mu         sync.Mutex
+	todos      bool
+}
+
+// NewReport returns an empty Report. If todos is set, a
+// "// TODO(fiber-migrate):" comment is inserted at every finding.
+func NewReport(todos bool) *Report {
+	return &Report{todos: todos}
+}
+
+type reportKey struct{}
+
+// WithReport returns a copy of ctx that carries the report.
+func WithReport(ctx context.Context, r *Report) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+// ReportFromContext returns the report carried by ctx or nil.
+func ReportFromContext(ctx context.Context) *Report {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(reportKey{}).(*Report) //nolint:errcheck // nil if absent
+	return r
+}
+
+// SetStage sets the name of the migration step that is responsible for the
+// following findings.
+func (r *Report) SetStage(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stage = name
+}
+
+// TODOs reports whether TODO comments should be inserted.
+func (r *Report) TODOs() bool {
+	return r.todos
+}
+
+// Add records the findings for the current migration step. A finding that
+// was already reported for the same file, line and message is dropped.
+func (r *Report) Add(findings ...Finding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range findings {
+		if r.seen(f) {
+			continue
+		}
+		if f.Migration == "" {
+			f.Migration = r.stage
+		}
+		r.findings = append(r.findings, f)
+	}
+}
+
+func (r *Report) seen(f Finding) bool {
+	for _, o := range r.findings {
+		if o.File == f.File && o.Line == f.Line && o.Message == f.Message {
+			return true
+		}
+	}
+	return false
+}
+
+// Findings returns the findings sorted by file and line. Findings on the same
+// line keep the order they were reported in.
+func (r *Report) Findings() []Finding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	findings := append([]Finding(nil), r.findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// AddMigration records the result of a migration.
+func (r *Report) AddMigration(m MigrationResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.migrations = append(r.migrations, m)
+}
+
+// Migrations returns the migration results in the order they were recorded.
+func (r *Report) Migrations() []MigrationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]MigrationResult(nil), r.migrations...)
+}
@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 
+	"github.com/gofiber/cli/cmd/internal"
 	"github.com/gofiber/cli/cmd/internal/migrations"
 )
 
@@ -16,13 +20,29 @@ func newMigrateCmd() *cobra.Command {
 	var targetVersionS string
 	var force bool
 	var skipGoMod bool
+	var dryRun bool
+	var keepPartial bool
+	var only []string
+	var todo bool
+	var skip []string
+	var output string
+	var exclude []string
+	var includeGenerated bool
+	var external bool
+	var rules []string
+	var verify string
+	var allowDirty bool
+	var branch string
+	var commitEach bool
 
 	cmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "Migrate Fiber project version to a newer version",
 	}
 
-	latestFiberVersion, err := LatestFiberVersion()
+	// the example comes from the embedded release table, building the
+	// command must not wait for the network
+	latestFiberVersion, err := latestEmbeddedVersion(fiberModulePath)
 	if err != nil {
 		latestFiberVersion = ""
 	}
@@ -33,6 +53,21 @@ func newMigrateCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Force migration even if already on version")
 	cmd.Flags().BoolVarP(&skipGoMod, "skip_go_mod", "s", false, "Skip running go mod tidy, download and vendor")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print a diff of the changes without writing them, exits non-zero if files would change")
+	cmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "Keep the changes of successful steps instead of rolling back when a step fails")
+	cmd.Flags().StringSliceVar(&only, "only", nil, "Run only the given migrations e.g:MigrateCORSConfig,MigrateCSRFConfig")
+	cmd.Flags().BoolVar(&todo, "todo", false, "Insert // TODO(fiber-migrate): comments where manual follow-up is needed")
+	cmd.Flags().StringSliceVar(&skip, "skip", nil, "Skip the given migrations e.g:MigrateStaticRoutes")
+	cmd.Flags().StringVarP(&output, "output", "o", outputText, "Output format: text|json")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Do not migrate files matching the glob patterns e.g:internal/legacy,*_mock.go")
+	cmd.Flags().BoolVar(&includeGenerated, "include-generated", false, "Migrate generated files as well")
+	cmd.Flags().BoolVar(&external, "external", false, "Run the "+migrations.ExternalPrefix+"* executables found in PATH")
+	cmd.Flags().StringSliceVar(&rules, "rules", nil, "Apply the rules of the YAML files e.g:rules.yaml")
+	cmd.Flags().StringVar(&verify, "verify", "", "Run go build and go vet in the migrated modules, --verify=test runs go test as well")
+	cmd.Flags().Lookup("verify").NoOptDefVal = verifyBuild
+	cmd.Flags().BoolVar(&allowDirty, "allow-dirty", false, "Migrate even if the git working tree has uncommitted changes")
+	cmd.Flags().StringVar(&branch, "branch", "", "Create the git branch and switch to it before writing the migration")
+	cmd.Flags().BoolVar(&commitEach, "commit-each", false, "Make a git commit for every migration step")
 
 	cmd.RunE = func(cmd *cobra.Command, _ []string) error {
 		return migrateRunE(cmd, MigrateOptions{
@@ -40,9 +75,48 @@ func newMigrateCmd() *cobra.Command {
 			TargetVersionS:     targetVersionS,
 			Force:              force,
 			SkipGoMod:          skipGoMod,
+			DryRun:             dryRun,
+			KeepPartial:        keepPartial,
+			Only:               only,
+			Skip:               skip,
+			TODO:               todo,
+			Output:             output,
+			Exclude:            append(append([]string(nil), rc.MigrateExclude...), exclude...),
+			IncludeGenerated:   includeGenerated,
+			External:           external,
+			Rules:              rules,
+			Verify:             verify,
+			AllowDirty:         allowDirty,
+			Branch:             branch,
+			CommitEach:         commitEach,
 		})
 	}
 
+	cmd.AddCommand(newMigrateListCmd())
+
+	return cmd
+}
+
+func newMigrateListCmd() *cobra.Command {
+	var targetVersionS string
+	var external bool
+	var rules []string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the migrations that apply to the project",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return migrateListRunE(cmd, currentVersionFile, targetVersionS, rules, external)
+		},
+	}
+
+	cmd.Flags().StringVarP(&targetVersionS, "to", "t", "", "Target version e.g:3.0.0 Format: X.Y.Z")
+	if err := cmd.MarkFlagRequired("to"); err != nil {
+		panic(err)
+	}
+	cmd.Flags().BoolVar(&external, "external", false, "List the "+migrations.ExternalPrefix+"* executables found in PATH")
+	cmd.Flags().StringSliceVar(&rules, "rules", nil, "List the rules of the YAML files e.g:rules.yaml")
+
 	return cmd
 }
 
@@ -53,15 +127,51 @@ type MigrateOptions struct {
 	TargetVersionS     string
 	Force              bool
 	SkipGoMod          bool
+	DryRun             bool
+	KeepPartial        bool
+	Only               []string
+	Skip               []string
+	TODO               bool
+	Output             string
+	Exclude            []string
+	IncludeGenerated   bool
+	External           bool
+	Rules              []string
+	Verify             string
+	AllowDirty         bool
+	Branch             string
+	CommitEach         bool
 }
 
-func migrateRunE(cmd *cobra.Command, opts MigrateOptions) error {
-	currentVersionS, err := currentVersionFromFile(opts.CurrentVersionFile)
-	if err != nil {
-		return fmt.Errorf("current fiber project version not found: %w", err)
+func migrateRunE(cmd *cobra.Command, opts MigrateOptions) (err error) {
+	res := &migrateResult{dryRun: opts.DryRun}
+	switch opts.Output {
+	case outputText, "":
+	case outputJSON:
+		// the document is the only output, the progress messages are dropped
+		w := cmd.OutOrStdout()
+		cmd.SetOut(io.Discard)
+		cmd.SilenceUsage = true
+		defer func() { err = writeMigrateJSON(w, res, err) }()
+	default:
+		return fmt.Errorf("invalid output format %q, use %s or %s", opts.Output, outputText, outputJSON)
+	}
+
+	if err := validVerifyMode(opts.Verify); err != nil {
+		return err
+	}
+	if opts.Verify != "" && opts.DryRun {
+		return errors.New("--verify cannot be combined with --dry-run, a dry run writes nothing to verify")
+	}
+
+	if err := loadMigrations(cmd, opts.Rules, opts.External); err != nil {
+		return err
+	}
+
+	selection := migrations.Selection{Only: opts.Only, Skip: opts.Skip}
+	if err := selection.Validate(); err != nil {
+		return fmt.Errorf("invalid migration selection: %w", err)
 	}
-	currentVersionS = strings.TrimPrefix(currentVersionS, "v")
-	currentVersion := semver.MustParse(currentVersionS)
 
 	opts.TargetVersionS = strings.TrimPrefix(opts.TargetVersionS, "v")
 	targetVersion, err := semver.NewVersion(opts.TargetVersionS)
@@ -69,29 +179,374 @@ func migrateRunE(cmd *cobra.Command, opts MigrateOptions) error {
 		return fmt.Errorf("invalid version for \"%s\": %w", opts.TargetVersionS, err)
 	}
 
-	if !targetVersion.GreaterThan(currentVersion) && !(opts.Force && targetVersion.Equal(currentVersion)) {
-		return fmt.Errorf("target version v%s is not greater than current version v%s", opts.TargetVersionS, currentVersionS)
-	}
-
 	wd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("cannot get current working directory: %w", err)
 	}
+	res.wd = wd
+
+	modules, err := fiberModules(wd)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		if _, err := projectFiberVersion(opts.CurrentVersionFile); err != nil {
+			return fmt.Errorf("current fiber project version not found: %w", err)
+		}
+		return errors.New("current fiber project version not found")
+	}
+
+	var pending []fiberModule
+	for _, m := range modules {
+		if targetVersion.GreaterThan(m.version) || (opts.Force && targetVersion.Equal(m.version)) {
+			pending = append(pending, m)
+			continue
+		}
+		if len(modules) > 1 {
+			cmd.Printf("Skipping %s, already on Fiber %s\n", relPath(wd, m.dir), m.version.Original())
+		}
+	}
+	res.modules, res.pending = modules, pending
+	if len(pending) == 0 {
+		return fmt.Errorf("target version v%s is not greater than current version v%s", opts.TargetVersionS, modules[0].version.Original())
+	}
+	currentVersionS := pending[0].version.Original()
+	res.from, res.to = currentVersionS, opts.TargetVersionS
+
+	var repo *gitRepo
+	if !opts.DryRun {
+		if repo, err = openMigrateRepo(wd, opts); err != nil {
+			return err
+		}
+	}
+
+	// stage all changes in memory, nothing is written before every migration succeeded
+	overlay := internal.NewOverlay()
+	defer func() {
+		if cerr := overlay.Close(); cerr != nil {
+			cmd.PrintErrf("failed to remove backups: %v\n", cerr)
+		}
+	}()
+	report := internal.NewReport(opts.TODO)
+	ctx := internal.WithReport(internal.WithOverlay(cmd.Context(), overlay), report)
+	cmd.SetContext(internal.WithFileFilter(ctx, internal.FileFilter{
+		Root:             wd,
+		Exclude:          opts.Exclude,
+		IncludeGenerated: opts.IncludeGenerated,
+	}))
+	res.overlay, res.report = overlay, report
+
+	if err := migrateModules(cmd, wd, pending, targetVersion, selection); err != nil {
+		if !opts.KeepPartial || opts.DryRun {
+			return fmt.Errorf("migration failed, no files were changed: %w", err)
+		}
+		if cerr := overlay.Commit(); cerr != nil {
+			return fmt.Errorf("migration failed %w, keeping partial changes failed: %w", err, cerr)
+		}
+		return fmt.Errorf("migration failed, partial changes were kept: %w", err)
+	}
+
+	printFindings(cmd, wd, report.Findings())
+
+	if opts.DryRun {
+		return printDryRun(cmd, wd, overlay, currentVersionS, opts.TargetVersionS)
+	}
+
+	dirs := moduleDirs(pending)
+	if err := commitMigration(cmd, res, dirs, repo, opts); err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		msg := fmt.Sprintf("Migration from Fiber %s to %s", m.version.Original(), opts.TargetVersionS)
+		if len(modules) > 1 || m.dir != wd {
+			msg = fmt.Sprintf("Migration of %s from Fiber %s to %s", relPath(wd, m.dir), m.version.Original(), opts.TargetVersionS)
+		}
+		cmd.Println(termenv.String(msg).
+			Foreground(termenv.ANSIBrightBlue))
+	}
+	for _, c := range res.commits {
+		cmd.Printf("Committed %.7s %s\n", c.Hash, c.Message)
+	}
+
+	if opts.Verify != "" {
+		cmd.Println("Verifying the migrated modules")
+		res.verify = runVerify(dirs, opts.Verify, overlay)
+		return printVerify(cmd, wd, res.verify)
+	}
+
+	return nil
+}
+
+// migrateModules runs the migrations for every module with its own current
+// version and updates the go.work file of a workspace afterwards.
+func migrateModules(cmd *cobra.Command, wd string, modules []fiberModule, target *semver.Version, sel migrations.Selection) error {
+	dirs := make([]string, 0, len(modules))
+	for _, m := range modules {
+		if len(modules) > 1 || m.dir != wd {
+			cmd.Printf("Migrating %s from Fiber %s\n", relPath(wd, m.dir), m.version.Original())
+		}
+		if err := migrations.DoMigration(cmd, m.dir, m.version, target, sel); err != nil {
+			return err
+		}
+		dirs = append(dirs, m.dir)
+	}
+
+	if err := migrations.MigrateGoWork(cmd, wd, dirs); err != nil {
+		return fmt.Errorf("update go.work: %w", err)
+	}
+	return nil
+}
+
+// relPath returns path relative to wd using forward slashes.
+func relPath(wd, path string) string {
+	if r, err := filepath.Rel(wd, path); err == nil {
+		return filepath.ToSlash(r)
+	}
+	return path
+}
+
+// migrateListRunE prints every migration function that applies to the
+// project for the given target version together with its description.
+func migrateListRunE(cmd *cobra.Command, versionFile, targetVersionS string, rules []string, external bool) error {
+	if err := loadMigrations(cmd, rules, external); err != nil {
+		return err
+	}
+
+	currentVersion, err := projectFiberVersion(versionFile)
+	if err != nil {
+		return fmt.Errorf("current fiber project version not found: %w", err)
+	}
+	currentVersionS := currentVersion.Original()
+
+	targetVersionS = strings.TrimPrefix(targetVersionS, "v")
+	targetVersion, err := semver.NewVersion(targetVersionS)
+	if err != nil {
+		return fmt.Errorf("invalid version for \"%s\": %w", targetVersionS, err)
+	}
+
+	msg := fmt.Sprintf("Migrations from Fiber %s to %s", currentVersionS, targetVersionS)
+	cmd.Println(termenv.String(msg).Foreground(termenv.ANSIBrightBlue))
+
+	hops := migrations.Hops(currentVersion, targetVersion)
+	for _, h := range hops {
+		applicable, err := migrations.Applicable(h.From, h.To)
+		if err != nil {
+			return fmt.Errorf("find migrations: %w", err)
+		}
 
-	err = migrations.DoMigration(cmd, wd, currentVersion, targetVersion)
+		var names, descriptions []string
+		width := 0
+		for _, m := range applicable {
+			for i := range m.Functions {
+				names = append(names, m.Name(i))
+				descriptions = append(descriptions, m.Description(i))
+				width = max(width, len(m.Name(i)))
+			}
+		}
+
+		if len(hops) > 1 {
+			cmd.Printf("From Fiber %s:\n", h)
+		}
+		if len(names) == 0 {
+			cmd.Println("No migrations apply")
+			continue
+		}
+		for i, name := range names {
+			cmd.Printf("  %-*s  %s\n", width, name, descriptions[i])
+		}
+	}
+
+	return nil
+}
+
+// loadMigrations makes the rules of the rules files and, if external is set,
+// the fiber-migrate-* executables in PATH available as migrations.
+func loadMigrations(cmd *cobra.Command, rules []string, external bool) error {
+	if err := migrations.LoadRules(rules...); err != nil {
+		return err //nolint:wrapcheck // errors name the rules file
+	}
+
+	pathList := ""
+	if external {
+		pathList = os.Getenv("PATH")
+	}
+	warnings, err := migrations.LoadExternal(cmd.Context(), pathList)
+	for _, w := range warnings {
+		cmd.PrintErrln(termenv.String(fmt.Sprintf("WARNING: skipping external migration: %s", w)).Foreground(termenv.ANSIBrightYellow))
+	}
 	if err != nil {
-		return fmt.Errorf("migration failed %w", err)
+		return fmt.Errorf("load external migrations: %w", err)
 	}
+	return nil
+}
+
+// printFindings prints the changes that need manual follow-up.
+func printFindings(cmd *cobra.Command, wd string, findings []internal.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("%d change(s) need manual follow-up:", len(findings))
+	cmd.Println(termenv.String(msg).Foreground(termenv.ANSIBrightYellow))
+	for _, f := range findings {
+		cmd.Printf("  %s:%d: %s: %s. %s\n", relPath(wd, f.File), f.Line, f.Migration, f.Message, f.Action)
+	}
+}
 
-	if !opts.SkipGoMod {
-		if err := runGoMod(wd); err != nil {
-			return fmt.Errorf("go mod: %w", err)
+// openMigrateRepo returns the git repository of wd and refuses to migrate a
+// working tree with uncommitted changes unless they are allowed. It returns
+// nil if wd is not part of a repository and no option needs one.
+func openMigrateRepo(wd string, opts MigrateOptions) (*gitRepo, error) {
+	repo, err := openGitRepo(wd)
+	if err != nil {
+		if opts.Branch != "" || opts.CommitEach {
+			return nil, fmt.Errorf("--branch and --commit-each need a git repository: %w", err)
 		}
+		return nil, nil //nolint:nilnil // git is optional
+	}
+	if opts.AllowDirty {
+		return repo, nil
 	}
 
-	msg := fmt.Sprintf("Migration from Fiber %s to %s", currentVersionS, opts.TargetVersionS)
-	cmd.Println(termenv.String(msg).
-		Foreground(termenv.ANSIBrightBlue))
+	dirty, err := repo.dirty()
+	if err != nil {
+		return nil, err
+	}
+	if len(dirty) > 0 {
+		return nil, fmt.Errorf("the working tree has uncommitted changes, commit or stash them or pass --allow-dirty:\n%s", strings.Join(dirty, "\n"))
+	}
+	return repo, nil
+}
+
+// commitMigration writes the staged migration to disk and runs the go mod
+// commands in dirs. If a step fails, the touched files, go.sum, go.work and
+// vendor directories are restored and the commits made with --commit-each
+// are reset unless partial changes should be kept.
+func commitMigration(cmd *cobra.Command, res *migrateResult, dirs []string, repo *gitRepo, opts MigrateOptions) (err error) {
+	wd, overlay := res.wd, res.overlay
+	// branch is set once the branch of --branch was created
+	branch := ""
+	defer func() {
+		if err == nil || opts.KeepPartial {
+			return
+		}
+		if rerr := overlay.Rollback(); rerr != nil {
+			err = fmt.Errorf("%w (rollback failed: %w)", err, rerr)
+			return
+		}
+		if repo != nil {
+			if rerr := repo.rollback(branch); rerr != nil {
+				err = fmt.Errorf("%w (resetting git failed: %w)", err, rerr)
+				return
+			}
+		}
+		msg := "Migration rolled back, no files were changed"
+		cmd.Println(termenv.String(msg).Foreground(termenv.ANSIBrightYellow))
+	}()
+
+	if repo != nil && opts.Branch != "" {
+		if err := repo.createBranch(opts.Branch); err != nil {
+			return err
+		}
+		branch = opts.Branch
+		cmd.Printf("Switched to a new branch %s\n", opts.Branch)
+	}
+
+	if repo != nil && opts.CommitEach {
+		if err := commitStages(res, repo); err != nil {
+			return err
+		}
+	} else if err := overlay.Commit(); err != nil {
+		return fmt.Errorf("write migrated files: %w", err)
+	}
+
+	if opts.SkipGoMod {
+		return nil
+	}
+
+	var goFiles []string
+	for _, dir := range dirs {
+		goFiles = append(goFiles, filepath.Join(dir, "go.mod"), filepath.Join(dir, "go.sum"), filepath.Join(dir, "vendor"))
+		if err := overlay.Track(filepath.Join(dir, "go.mod")); err != nil {
+			return fmt.Errorf("backup go.mod: %w", err)
+		}
+		if err := overlay.Track(filepath.Join(dir, "go.sum")); err != nil {
+			return fmt.Errorf("backup go.sum: %w", err)
+		}
+		if err := overlay.TrackDir(filepath.Join(dir, "vendor")); err != nil {
+			return fmt.Errorf("backup vendor: %w", err)
+		}
+	}
+	if isWorkspace(wd) {
+		for _, name := range []string{"go.work", "go.work.sum"} {
+			goFiles = append(goFiles, filepath.Join(wd, name))
+			if err := overlay.Track(filepath.Join(wd, name)); err != nil {
+				return fmt.Errorf("backup %s: %w", name, err)
+			}
+		}
+	}
+
+	res.goMod, err = runGoMod(wd, dirs, opts.Output == outputJSON)
+	if err != nil {
+		return fmt.Errorf("go mod: %w", err)
+	}
+
+	if repo != nil && opts.CommitEach {
+		if err := res.commit(repo, "go mod", "Update the module requirements with go mod", goFiles...); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+// commitStages writes the changes of every migration step to disk and
+// commits them one step at a time.
+func commitStages(res *migrateResult, repo *gitRepo) error {
+	for _, stage := range res.overlay.Stages() {
+		paths, err := res.overlay.CommitStage(stage)
+		if err != nil {
+			return fmt.Errorf("write migrated files: %w", err)
+		}
+		if err := res.commit(repo, stage, migrations.Describe(stage), paths...); err != nil {
+			return err
+		}
+	}
+	// changes made outside of a migration step are left uncommitted
+	if err := res.overlay.Commit(); err != nil {
+		return fmt.Errorf("write migrated files: %w", err)
+	}
+	return nil
+}
+
+// printDryRun prints a unified diff for every file changed in the overlay and a
+// summary of the migrations that changed them. It returns an error if any file
+// would be changed so the dry run can be used to gate CI.
+func printDryRun(cmd *cobra.Command, wd string, overlay *internal.Overlay, from, to string) error {
+	rel := func(path string) string { return relPath(wd, path) }
+
+	changed := overlay.Changed()
+	for _, path := range changed {
+		cmd.Print(overlay.Diff(path, rel(path)))
+	}
+
+	msg := fmt.Sprintf("Dry run of migration from Fiber %s to %s", from, to)
+	cmd.Println(termenv.String(msg).Foreground(termenv.ANSIBrightBlue))
+
+	if len(changed) == 0 {
+		cmd.Println("No files would be changed")
+		return nil
+	}
+
+	for _, stage := range overlay.Stages() {
+		files := overlay.Touched(stage)
+		for i, path := range files {
+			files[i] = rel(path)
+		}
+		cmd.Printf("  %s: %s\n", stage, strings.Join(files, ", "))
+	}
+
+	cmd.SilenceUsage = true
+	return fmt.Errorf("dry run: %d file(s) would be changed", len(changed))
+}
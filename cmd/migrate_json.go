@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// migrateResult collects the state of a migration run for the JSON output.
+type migrateResult struct {
+	overlay *internal.Overlay
+	report  *internal.Report
+	wd      string
+	from    string
+	to      string
+	modules []fiberModule
+	pending []fiberModule
+	goMod   []goModResult
+	verify  []verifyResult
+	commits []gitCommit
+	dryRun  bool
+}
+
+type migrateJSON struct {
+	CurrentVersion string          `json:"current_version"`
+	TargetVersion  string          `json:"target_version"`
+	Error          string          `json:"error,omitempty"`
+	Modules        []moduleJSON    `json:"modules"`
+	Migrations     []migrationJSON `json:"migrations"`
+	Warnings       []warningJSON   `json:"warnings"`
+	GoMod          []goModResult   `json:"go_mod"`
+	Verify         []verifyResult  `json:"verify,omitempty"`
+	Commits        []gitCommit     `json:"commits,omitempty"`
+	DryRun         bool            `json:"dry_run"`
+	Success        bool            `json:"success"`
+}
+
+type moduleJSON struct {
+	Dir            string `json:"dir"`
+	CurrentVersion string `json:"current_version"`
+	Migrated       bool   `json:"migrated"`
+}
+
+type migrationJSON struct {
+	Module    string         `json:"module"`
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Hop       string         `json:"hop,omitempty"`
+	Functions []functionJSON `json:"functions,omitempty"`
+	Skipped   []string       `json:"skipped,omitempty"`
+	Applied   bool           `json:"applied"`
+}
+
+type functionJSON struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
+
+type warningJSON struct {
+	File      string `json:"file"`
+	Migration string `json:"migration"`
+	Message   string `json:"message"`
+	Action    string `json:"action"`
+	Line      int    `json:"line"`
+}
+
+// writeMigrateJSON writes the result of the migration run to w. runErr is the
+// error the run ended with, it is returned unchanged unless encoding fails.
+func writeMigrateJSON(w io.Writer, res *migrateResult, runErr error) error {
+	doc := migrateJSON{
+		CurrentVersion: res.from,
+		TargetVersion:  res.to,
+		DryRun:         res.dryRun,
+		Success:        runErr == nil,
+		Modules:        []moduleJSON{},
+		Migrations:     []migrationJSON{},
+		Warnings:       []warningJSON{},
+		GoMod:          []goModResult{},
+	}
+	if runErr != nil {
+		doc.Error = runErr.Error()
+	}
+
+	for _, m := range res.modules {
+		migrated := false
+		for _, p := range res.pending {
+			migrated = migrated || p.dir == m.dir
+		}
+		doc.Modules = append(doc.Modules, moduleJSON{
+			Dir:            res.rel(m.dir),
+			CurrentVersion: m.version.Original(),
+			Migrated:       migrated,
+		})
+	}
+
+	if res.report != nil {
+		migrations := res.report.Migrations()
+		dirs := make([]string, 0, len(migrations))
+		for _, m := range migrations {
+			dirs = append(dirs, m.Module)
+		}
+		for _, m := range migrations {
+			mj := migrationJSON{Module: res.rel(m.Module), From: m.From, To: m.To, Hop: m.Hop, Applied: m.Applied, Skipped: m.Skipped}
+			for _, name := range m.Functions {
+				files := []string{}
+				if res.overlay != nil {
+					for _, path := range res.overlay.Touched(name) {
+						if moduleOf(dirs, path) == m.Module {
+							files = append(files, res.rel(path))
+						}
+					}
+				}
+				mj.Functions = append(mj.Functions, functionJSON{Name: name, Files: files})
+			}
+			doc.Migrations = append(doc.Migrations, mj)
+		}
+
+		for _, f := range res.report.Findings() {
+			doc.Warnings = append(doc.Warnings, warningJSON{
+				File:      res.rel(f.File),
+				Line:      f.Line,
+				Migration: f.Migration,
+				Message:   f.Message,
+				Action:    f.Action,
+			})
+		}
+	}
+
+	for _, r := range res.goMod {
+		r.Dir = res.rel(r.Dir)
+		doc.GoMod = append(doc.GoMod, r)
+	}
+
+	for _, r := range res.verify {
+		r.Dir = res.rel(r.Dir)
+		errs := make([]verifyError, 0, len(r.Errors))
+		for _, e := range r.Errors {
+			e.File = res.rel(e.File)
+			errs = append(errs, e)
+		}
+		r.Errors = errs
+		doc.Verify = append(doc.Verify, r)
+	}
+
+	doc.Commits = res.commits
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		if runErr != nil {
+			return runErr
+		}
+		return fmt.Errorf("encode json: %w", err)
+	}
+
+	return runErr
+}
+
+// moduleOf returns the innermost of the module dirs that contains path, the
+// stages of a migration function are shared by all modules of a run.
+func moduleOf(dirs []string, path string) string {
+	module := ""
+	for _, dir := range dirs {
+		r, err := filepath.Rel(dir, path)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(dir) > len(module) {
+			module = dir
+		}
+	}
+	return module
+}
+
+// rel returns path relative to the working directory of the run.
+func (res *migrateResult) rel(path string) string {
+	if res.wd == "" {
+		return path
+	}
+	return relPath(res.wd, path)
+}
@@ -1,13 +1,21 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/gofiber/cli/cmd/internal/migrations"
 )
 
 func readFileTB(tb testing.TB, path string) string {
@@ -78,7 +86,7 @@ func main() {
 	content := readFileTB(t, filepath.Join(dir, "main.go"))
 	at := assert.New(t)
 	at.Contains(content, "github.com/gofiber/fiber/v3")
-	at.Contains(content, "github.com/gofiber/contrib/monitor")
+	at.Contains(content, "github.com/gofiber/contrib/v3/monitor")
 	at.NotContains(content, "*fiber.Ctx")
 	at.Contains(content, "fiber.Ctx")
 	at.Contains(content, ".Bind().Body(&v)")
@@ -128,15 +136,22 @@ require github.com/gofiber/fiber/v2 v2.0.0`
 		needError = false
 	}()
 
-	require.NoError(t, runGoMod(dir))
+	results, err := runGoMod(dir, nil, false)
+	require.NoError(t, err)
 	assert.Len(t, cmds, 3)
 	for _, c := range cmds {
 		assert.Equal(t, dir, c.Dir)
 	}
+	require.Len(t, results, 3)
+	assert.Equal(t, "go mod tidy", results[0].Command)
+	assert.Equal(t, dir, results[0].Dir)
 
 	cmds = nil
 	needError = true
-	assert.Error(t, runGoMod(dir))
+	results, err = runGoMod(dir, nil, true)
+	require.Error(t, err)
+	require.Len(t, results, 1)
+	assert.NotEmpty(t, results[0].Error)
 }
 
 func Test_Migrate_ForceAndSkip(t *testing.T) {
@@ -200,3 +215,744 @@ require github.com/gofiber/fiber/v3 v3.0.0
 		assert.Empty(t, cmds)
 	})
 }
+
+func Test_Migrate_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func handler(c *fiber.Ctx) error {
+	return c.SendString("ok")
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	setupCmd()
+	defer teardownCmd()
+
+	cmd := newMigrateCmd()
+	out, err := runCobraCmd(cmd, "-t=3.0.0", "--dry-run")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 file(s) would be changed")
+
+	at := assert.New(t)
+	at.Contains(out, "--- a/main.go\n+++ b/main.go\n")
+	at.Contains(out, "-import \"github.com/gofiber/fiber/v2\"\n+import \"github.com/gofiber/fiber/v3\"\n")
+	at.Contains(out, "-func handler(c *fiber.Ctx) error {\n+func handler(c fiber.Ctx) error {\n")
+	at.Contains(out, "+require github.com/gofiber/fiber/v3 v3.0.0")
+	at.Contains(out, "Dry run of migration from Fiber 2.0.6 to 3.0.0")
+	at.Contains(out, "MigrateGoPkgs: go.mod, main.go")
+	at.Contains(out, "MigrateHandlerSignatures: main.go")
+	at.Contains(out, "MigrateGoVersion: go.mod")
+
+	// nothing was written
+	at.Equal(main, readFileTB(t, filepath.Join(dir, "main.go")))
+	at.Equal(gomod, readFileTB(t, filepath.Join(dir, "go.mod")))
+}
+
+// setupMigrateProject writes the files, keyed by slash separated paths, to a
+// temporary directory and makes it the working directory until the test ends.
+func setupMigrateProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+func Test_Migrate_Rollback(t *testing.T) {
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func handler(c *fiber.Ctx) error {
+	return c.SendString("ok")
+}
+`
+	setup := func(t *testing.T) string {
+		t.Helper()
+		dir := setupMigrateProject(t, map[string]string{
+			"go.mod":             gomod,
+			"go.sum":             "sum",
+			"main.go":            main,
+			"vendor/modules.txt": "vendored",
+		})
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "example"), 0o750))
+		return dir
+	}
+
+	failing := func(t *testing.T) {
+		t.Helper()
+		orig := migrations.Migrations
+		migrations.Migrations = append(append([]migrations.Migration(nil), orig...), migrations.Migration{
+			From: ">=2.0.0",
+			To:   "<4.0.0-0",
+			Functions: []migrations.MigrationFn{
+				func(_ *cobra.Command, _ string, _, _ *semver.Version) error {
+					return errors.New("boom")
+				},
+			},
+		})
+		t.Cleanup(func() { migrations.Migrations = orig })
+	}
+
+	t.Run("failing step", func(t *testing.T) {
+		dir := setup(t)
+		failing(t)
+
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0")
+		require.Error(t, err)
+		assert.Contains(t, out, "no files were changed")
+		assert.Equal(t, main, readFileTB(t, filepath.Join(dir, "main.go")))
+		assert.Equal(t, gomod, readFileTB(t, filepath.Join(dir, "go.mod")))
+	})
+
+	t.Run("failing step keep partial", func(t *testing.T) {
+		dir := setup(t)
+		failing(t)
+
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "--keep-partial")
+		require.Error(t, err)
+		assert.Contains(t, out, "partial changes were kept")
+		assert.Contains(t, readFileTB(t, filepath.Join(dir, "main.go")), "c fiber.Ctx")
+		assert.Contains(t, readFileTB(t, filepath.Join(dir, "go.mod")), "github.com/gofiber/fiber/v3 v3.0.0")
+	})
+
+	t.Run("failing go mod", func(t *testing.T) {
+		dir := setup(t)
+		setupCmd(errFlag)
+		defer teardownCmd()
+
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0")
+		require.Error(t, err)
+		assert.Contains(t, out, "Migration rolled back")
+		assert.Equal(t, main, readFileTB(t, filepath.Join(dir, "main.go")))
+		assert.Equal(t, gomod, readFileTB(t, filepath.Join(dir, "go.mod")))
+		assert.Equal(t, "sum", readFileTB(t, filepath.Join(dir, "go.sum")))
+		assert.Equal(t, "vendored", readFileTB(t, filepath.Join(dir, "vendor", "modules.txt")))
+	})
+}
+
+func Test_Migrate_OnlySkip(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func main() {
+	app := fiber.New()
+	app.Mount("/api", app)
+	app.Static("/", "./public")
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--only=MigrateNothing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown migration "MigrateNothing"`)
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--only=MigrateMount,MigrateStaticRoutes", "--skip=MigrateStaticRoutes")
+	require.NoError(t, err)
+
+	content := readFileTB(t, filepath.Join(dir, "main.go"))
+	assert.Contains(t, content, `app.Use("/api", app)`)
+	assert.Contains(t, content, `app.Static("/", "./public")`)
+	assert.Equal(t, gomod, readFileTB(t, filepath.Join(dir, "go.mod")))
+}
+
+func Test_Migrate_List(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	out, err := runCobraCmd(newMigrateCmd(), "list", "--to", "3.0.0")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Migrations from Fiber 2.0.6 to 3.0.0")
+	assert.Regexp(t, `MigrateGoPkgs +Rewrite Fiber import paths`, out)
+	assert.Regexp(t, `MigrateCORSConfig +Convert cors string options to slices`, out)
+	assert.Contains(t, out, "MigrateGoVersion")
+	assert.NotContains(t, out, "From Fiber")
+
+	// a migration across several major versions lists the steps per hop
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(strings.Replace(gomod, "fiber/v2 v2.0.6", "fiber v1.14.6", 1)), 0o600))
+	out, err = runCobraCmd(newMigrateCmd(), "list", "--to", "3.0.0")
+	require.NoError(t, err)
+	assert.Contains(t, out, "From Fiber v1.14.6 to v2.0.0:\n  MigrateGoPkgs  ")
+	assert.Contains(t, out, "From Fiber v2.0.0 to v3.0.0:\n  MigrateGoPkgs  ")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+
+	_, err = runCobraCmd(newMigrateCmd(), "list")
+	require.Error(t, err)
+}
+
+func Test_Migrate_Findings(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2/middleware/csrf"
+
+var _ = csrf.New(csrf.Config{
+	SessionKey: "csrf",
+})
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--todo")
+	require.NoError(t, err)
+	assert.Contains(t, out, "1 change(s) need manual follow-up:")
+	assert.Contains(t, out, "main.go:6: MigrateCSRFConfig: removed csrf.Config.SessionKey. Configure the Session store")
+	assert.Contains(t, readFileTB(t, filepath.Join(dir, "main.go")), "\t// TODO(fiber-migrate): removed csrf.Config.SessionKey.")
+}
+
+func Test_Migrate_OutputJSON(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2/middleware/csrf"
+
+var _ = csrf.New(csrf.Config{
+	SessionKey: "csrf",
+})
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	setupCmd()
+	defer teardownCmd()
+
+	out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "--output=json")
+	require.NoError(t, err)
+
+	var doc migrateJSON
+	require.NoError(t, json.Unmarshal([]byte(out), &doc), out)
+
+	at := assert.New(t)
+	at.Equal("2.0.6", doc.CurrentVersion)
+	at.Equal("3.0.0", doc.TargetVersion)
+	at.True(doc.Success)
+	at.False(doc.DryRun)
+
+	require.Len(t, doc.Migrations, 2)
+	at.Equal(">=1.0.0", doc.Migrations[0].From)
+	at.True(doc.Migrations[0].Applied)
+	at.Equal([]functionJSON{{Name: "MigrateGoPkgs", Files: []string{"go.mod", "main.go"}}}, doc.Migrations[0].Functions)
+	at.Contains(doc.Migrations[1].Functions, functionJSON{Name: "MigrateCSRFConfig", Files: []string{"main.go"}})
+	at.Contains(doc.Migrations[1].Functions, functionJSON{Name: "MigrateMount", Files: []string{}})
+
+	require.Len(t, doc.Warnings, 1)
+	at.Equal(warningJSON{
+		File:      "main.go",
+		Line:      6,
+		Migration: "MigrateCSRFConfig",
+		Message:   "removed csrf.Config.SessionKey",
+		Action:    "Configure the Session store and read the token with csrf.TokenFromContext",
+	}, doc.Warnings[0])
+
+	require.Len(t, doc.GoMod, 3)
+	at.Equal(goModResult{Dir: ".", Command: "go mod tidy"}, doc.GoMod[0])
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "--output=yaml")
+	require.Error(t, err)
+}
+
+func Test_Migrate_FiberModuleDirs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gomod := "module example\n\ngo 1.20\n\nrequire github.com/gofiber/fiber/v2 v2.0.6\n"
+	for _, sub := range []string{".", "app", "testdata/fix", "app/testdata/fix", ".cache/mod", "_old", "vendor/example"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, sub, "go.mod"), []byte(gomod), 0o600))
+	}
+
+	dirs, err := fiberModuleDirs(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{dir, filepath.Join(dir, "app")}, dirs)
+}
+
+func Test_Migrate_ModuleOf(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join("ws")
+	a := filepath.Join(root, "a")
+	ab := filepath.Join(root, "ab")
+	dirs := []string{root, a, ab}
+
+	at := assert.New(t)
+	at.Equal(root, moduleOf(dirs, filepath.Join(root, "main.go")))
+	at.Equal(a, moduleOf(dirs, filepath.Join(a, "main.go")))
+	at.Equal(a, moduleOf(dirs, filepath.Join(a, "go.mod")))
+	at.Equal(ab, moduleOf(dirs, filepath.Join(ab, "sub", "main.go")))
+	at.Empty(moduleOf(dirs[1:], filepath.Join(root, "main.go")))
+}
+
+func Test_Migrate_Workspace(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	module := func(name, version string) {
+		t.Helper()
+		major := "v" + version[:1]
+		write(name+"/go.mod", "module example.com/"+name+"\n\ngo 1.21\n\nrequire github.com/gofiber/fiber/"+major+" v"+version+"\n")
+		write(name+"/main.go", "package main\n\nimport \"github.com/gofiber/fiber/"+major+"\"\n\nfunc handler(c *fiber.Ctx) error { return nil }\n")
+	}
+
+	write("go.work", "go 1.21\n\nuse (\n\t./a\n\t./b\n)\n")
+	module("a", "2.0.6")
+	module("b", "2.50.0")
+	module("c", "2.40.0")
+	module("d", "3.0.0")
+	// nested module without fiber must not be touched
+	nested := "package tools\n\nimport _ \"github.com/gofiber/fiber/v2\"\n"
+	write("a/tools/go.mod", "module example.com/a/tools\n\ngo 1.21\n")
+	write("a/tools/tools.go", nested)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	origExec := execCommand
+	var cmds []*exec.Cmd
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, args...)
+		cmd := exec.Command(os.Args[0], cs...) // #nosec G204 -- safe for test
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+		cmds = append(cmds, cmd)
+		return cmd
+	}
+	defer func() { execCommand = origExec }()
+
+	out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0")
+	require.NoError(t, err)
+
+	at := assert.New(t)
+	at.Contains(out, "Skipping d, already on Fiber 3.0.0")
+	at.Contains(out, "Migrating b from Fiber 2.50.0")
+	at.Contains(out, "Migration of c from Fiber 2.40.0 to 3.0.0")
+
+	for _, name := range []string{"a", "b", "c"} {
+		at.Contains(readFileTB(t, filepath.Join(dir, name, "go.mod")), "github.com/gofiber/fiber/v3 v3.0.0")
+		at.Contains(readFileTB(t, filepath.Join(dir, name, "main.go")), "func handler(c fiber.Ctx) error")
+	}
+	at.Equal(nested, readFileTB(t, filepath.Join(dir, "a", "tools", "tools.go")))
+
+	work := readFileTB(t, filepath.Join(dir, "go.work"))
+	at.Contains(work, "go 1.24")
+	at.NotContains(work, "./c")
+	at.NotContains(work, "./d")
+	at.NotContains(out, "to go.work")
+
+	require.Len(t, cmds, 7)
+	last := cmds[len(cmds)-1]
+	at.Equal(dir, last.Dir)
+	at.Equal([]string{"go", "work", "sync"}, last.Args[len(last.Args)-3:])
+	for _, c := range cmds {
+		at.NotContains(c.Args, "vendor")
+	}
+}
+
+func Test_Migrate_PseudoVersion(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.0-20200926082917-55763e7e6ee3
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a pseudo-version")
+}
+
+func Test_Migrate_Exclude(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	src := "package main\n\nimport \"github.com/gofiber/fiber/v2\"\n\nfunc handler(c *fiber.Ctx) error { return nil }\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	for _, name := range []string{"main.go", "legacy.go", "mock.go", "routes_gen.go"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600))
+	}
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	origExclude := rc.MigrateExclude
+	rc.MigrateExclude = []string{"mock.go"}
+	defer func() { rc.MigrateExclude = origExclude }()
+
+	out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--exclude=legacy.go")
+	require.NoError(t, err)
+	assert.Contains(t, out, "routes_gen.go:1: MigrateGoPkgs: skipped generated file")
+
+	assert.Contains(t, readFileTB(t, filepath.Join(dir, "main.go")), "github.com/gofiber/fiber/v3")
+	for _, name := range []string{"legacy.go", "mock.go", "routes_gen.go"} {
+		assert.Equal(t, src, readFileTB(t, filepath.Join(dir, name)), name)
+	}
+}
+
+func Test_Migrate_Rules(t *testing.T) {
+	dir := t.TempDir()
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	src := `package main
+
+import (
+	"github.com/acme/fiberlog"
+	"github.com/gofiber/fiber/v2"
+)
+
+func main() {
+	app := fiber.New()
+	app.Use(fiberlog.NewLogger(fiberlog.Config{Color: true}))
+}
+`
+	rulesYAML := `rules:
+  - name: MigrateAcmeLog
+    description: Rename the acme logger API
+    import: github.com/acme/fiberlog
+    from: ">=2.0.0"
+    rename:
+      NewLogger: New
+    fields:
+      Config:
+        remove:
+          Color: Configure colors with a Theme
+  - name: MigrateAcmeLogV1
+    import: github.com/acme/fiberlog
+    from: "<2.0.0"
+    rename:
+      NewLogger: Old
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o600))
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(rulesYAML), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+	defer func() { require.NoError(t, migrations.LoadRules()) }()
+
+	out, err := runCobraCmd(newMigrateCmd(), "list", "-t=3.0.0", "--rules="+rulesFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "MigrateAcmeLog               Rename the acme logger API")
+	assert.NotContains(t, out, "MigrateAcmeLogV1")
+
+	out, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--rules="+rulesFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Applying rule MigrateAcmeLog")
+	assert.Contains(t, out, "main.go:10: MigrateAcmeLog: removed fiberlog.Config.Color. Configure colors with a Theme")
+
+	content := readFileTB(t, filepath.Join(dir, "main.go"))
+	assert.Contains(t, content, "github.com/gofiber/fiber/v3")
+	assert.Contains(t, content, "app.Use(fiberlog.New(fiberlog.Config{}))")
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--rules="+filepath.Join(dir, "missing.yaml"))
+	require.ErrorContains(t, err, "load rules")
+}
+
+func Test_Migrate_Verify(t *testing.T) {
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func handler(c *fiber.Ctx) error {
+	return c.SendString("ok")
+}
+`
+	origExec := execCommand
+	defer func() { execCommand = origExec }()
+
+	var commands []string
+	buildOutput := ""
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		command := name + " " + strings.Join(args, " ")
+		commands = append(commands, command)
+		if command == "go build ./..." && buildOutput != "" {
+			return exec.Command("sh", "-c", "printf '%s' \"$0\"; exit 1", buildOutput) // #nosec G204 -- safe for test
+		}
+		return exec.Command("true")
+	}
+
+	t.Run("passed", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+		commands = nil
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--verify=test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"go build ./...", "go vet ./...", "go test ./..."}, commands)
+		assert.Contains(t, out, "go vet ./... in .: ")
+		assert.Contains(t, out, "Verification passed")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+		commands = nil
+		buildOutput = "# example\n./main.go:6:9: c.SendString undefined\n"
+		defer func() { buildOutput = "" }()
+
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--verify", "-o", "json")
+		require.ErrorContains(t, err, "verification failed: 1 of 1 check(s) failed")
+		assert.Equal(t, []string{"go build ./..."}, commands)
+
+		// the error printed by cobra follows the document
+		var doc migrateJSON
+		require.NoError(t, json.NewDecoder(strings.NewReader(out)).Decode(&doc))
+		require.Len(t, doc.Verify, 1)
+		assert.False(t, doc.Verify[0].Passed)
+		assert.Equal(t, []verifyError{{
+			File:       "main.go",
+			Line:       6,
+			Column:     9,
+			Message:    "c.SendString undefined",
+			Migrations: []string{"MigrateGoPkgs", "MigrateHandlerSignatures"},
+		}}, doc.Verify[0].Errors)
+		// the migration itself is kept
+		assert.Contains(t, readFileTB(t, filepath.Join(dir, "main.go")), "github.com/gofiber/fiber/v3")
+	})
+
+	_, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "--verify=lint")
+	require.ErrorContains(t, err, `invalid verify mode "lint"`)
+
+	_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "--verify", "--dry-run")
+	require.ErrorContains(t, err, "--verify cannot be combined with --dry-run")
+}
+
+func Test_Migrate_Git(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "fiber")
+	t.Setenv("GIT_AUTHOR_EMAIL", "fiber@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "fiber")
+	t.Setenv("GIT_COMMITTER_EMAIL", "fiber@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	gomod := `module example
+
+go 1.20
+
+require github.com/gofiber/fiber/v2 v2.0.6
+`
+	main := `package main
+
+import "github.com/gofiber/fiber/v2"
+
+func handler(c *fiber.Ctx) error {
+	return c.Redirect("/")
+}
+`
+	git := func(t *testing.T, dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	setup := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+		git(t, dir, "init", "-q", "-b", "main")
+		git(t, dir, "add", "-A")
+		git(t, dir, "commit", "-q", "-m", "init")
+
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		t.Cleanup(func() { require.NoError(t, os.Chdir(cwd)) })
+		return dir
+	}
+
+	t.Run("dirty", func(t *testing.T) {
+		dir := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("wip\n"), 0o600))
+
+		_, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s")
+		require.ErrorContains(t, err, "uncommitted changes")
+		require.ErrorContains(t, err, "notes.txt")
+		b, err := os.ReadFile(filepath.Join(dir, "main.go")) // #nosec G304
+		require.NoError(t, err)
+		assert.Equal(t, main, string(b))
+
+		_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--allow-dirty")
+		require.NoError(t, err)
+		b, err = os.ReadFile(filepath.Join(dir, "main.go")) // #nosec G304
+		require.NoError(t, err)
+		assert.Contains(t, string(b), "c fiber.Ctx")
+		assert.Equal(t, "init", git(t, dir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("commit each", func(t *testing.T) {
+		dir := setup(t)
+
+		out, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--branch", "fiber-v3", "--commit-each")
+		require.NoError(t, err)
+		assert.Contains(t, out, "Switched to a new branch fiber-v3")
+		assert.Equal(t, "fiber-v3", git(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+		assert.Empty(t, git(t, dir, "status", "--porcelain"))
+
+		subjects := strings.Split(git(t, dir, "log", "--reverse", "--format=%s", "main..HEAD"), "\n")
+		assert.Contains(t, subjects, "fiber migrate: MigrateHandlerSignatures")
+		assert.Contains(t, subjects, "fiber migrate: MigrateRedirectMethods")
+		assert.Less(t, slices.Index(subjects, "fiber migrate: MigrateHandlerSignatures"), slices.Index(subjects, "fiber migrate: MigrateRedirectMethods"))
+		for _, s := range subjects {
+			assert.Contains(t, out, s)
+		}
+		assert.Contains(t, git(t, dir, "log", "-1", "--format=%b", "--grep", "MigrateHandlerSignatures"), "fiber.Ctx")
+	})
+
+	t.Run("commit failure", func(t *testing.T) {
+		dir := setup(t)
+		// git refuses to commit without an identity
+		t.Setenv("GIT_AUTHOR_NAME", "")
+		t.Setenv("GIT_COMMITTER_NAME", "")
+
+		_, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--branch", "fiber-v3", "--commit-each")
+		require.ErrorContains(t, err, "commit")
+		assert.Empty(t, git(t, dir, "status", "--porcelain"))
+		assert.Equal(t, "main", git(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+		assert.Empty(t, git(t, dir, "branch", "--list", "fiber-v3"))
+		assert.Equal(t, "init", git(t, dir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("commit failure keeps staged changes", func(t *testing.T) {
+		dir := setup(t)
+		staged := "// staged by the user\n" + main
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(staged), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("wip\n"), 0o600))
+		git(t, dir, "add", "main.go", "notes.txt")
+		t.Setenv("GIT_AUTHOR_NAME", "")
+		t.Setenv("GIT_COMMITTER_NAME", "")
+
+		_, err := runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--allow-dirty", "--commit-each")
+		require.ErrorContains(t, err, "commit")
+		assert.Equal(t, "M  main.go\nA  notes.txt", git(t, dir, "status", "--porcelain"))
+		assert.Equal(t, strings.TrimSpace(staged), git(t, dir, "show", ":main.go"))
+		assert.Equal(t, "init", git(t, dir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("no repository", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0o600))
+		t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dir))
+		defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+		_, err = runCobraCmd(newMigrateCmd(), "-t=3.0.0", "-s", "--commit-each")
+		require.ErrorContains(t, err, "need a git repository")
+	})
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/gofiber/cli/cmd/internal/migrations"
+)
+
+// MigrationFn is a step of fiber migrate. It is called with the directory of
+// the module being migrated and its current and target Fiber version.
+type MigrationFn = migrations.MigrationFn
+
+// Migration groups migration steps that run if the current version matches
+// the From and the target version the To constraint.
+type Migration = migrations.Migration
+
+// RegisterMigration adds a migration that fiber migrate runs after the
+// built-in ones. Programs embedding the fiber command call it before Execute.
+func RegisterMigration(m Migration) error {
+	return migrations.Register(m) //nolint:wrapcheck // errors describe the migration
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	_ "embed" // embeds the release table
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
+)
+
+// The sources of the latest released versions. auto tries GitHub, the Go
+// module proxies and the embedded release table in this order.
+const (
+	versionSourceAuto     = "auto"
+	versionSourceGitHub   = "github"
+	versionSourceProxy    = "proxy"
+	versionSourceEmbedded = "embedded"
+
+	versionSourceEnv = "FIBER_VERSION_SOURCE"
+)
+
+const (
+	fiberModulePath = "github.com/gofiber/fiber"
+	cliModulePath   = "github.com/gofiber/cli"
+)
+
+// versionSource is set by the --version-source flag.
+var versionSource string
+
+// releasesJSON holds the latest releases of every major version known when
+// the CLI was built, keyed by the module path without major version suffix.
+//
+//go:embed releases.json
+var releasesJSON []byte
+
+var (
+	// errModuleNotFound is returned by a proxy that does not know the module.
+	errModuleNotFound = errors.New("module not found")
+
+	// errNoEmbeddedCliRelease is returned for the CLI by the embedded source,
+	// a table built into the CLI can not know about newer CLI releases.
+	errNoEmbeddedCliRelease = errors.New("the embedded release table has no fiber cli releases, use --version-source github or proxy to check for cli updates")
+)
+
+// release is a module whose latest version can be looked up.
+type release struct {
+	repo   string // GitHub repository, e.g. gofiber/fiber
+	module string
+}
+
+var (
+	fiberRelease = release{repo: "gofiber/fiber", module: fiberModulePath}
+	cliRelease   = release{repo: "gofiber/cli", module: cliModulePath}
+)
+
+// selectedVersionSource returns the source of --version-source, of the
+// FIBER_VERSION_SOURCE environment variable or auto.
+func selectedVersionSource() (string, error) {
+	source := versionSource
+	if source == "" {
+		source = os.Getenv(versionSourceEnv)
+	}
+	switch source {
+	case "":
+		return versionSourceAuto, nil
+	case versionSourceAuto, versionSourceGitHub, versionSourceProxy, versionSourceEmbedded:
+		return source, nil
+	default:
+		return "", fmt.Errorf("invalid version source %q, use %s, %s, %s or %s",
+			source, versionSourceAuto, versionSourceGitHub, versionSourceProxy, versionSourceEmbedded)
+	}
+}
+
+// latest returns the latest released version of r without "v" prefix from
+// the selected source.
+func (r release) latest() (string, error) {
+	source, err := selectedVersionSource()
+	if err != nil {
+		return "", err
+	}
+
+	switch source {
+	case versionSourceGitHub:
+		return latestGitHubRelease(r.repo)
+	case versionSourceProxy:
+		return latestVersionByProxy(r.module)
+	case versionSourceEmbedded:
+		return latestEmbeddedVersion(r.module)
+	}
+
+	var errs []error
+	for _, lookup := range []func() (string, error){
+		func() (string, error) { return latestGitHubRelease(r.repo) },
+		func() (string, error) { return latestVersionByProxy(r.module) },
+		func() (string, error) { return latestEmbeddedVersion(r.module) },
+	} {
+		v, err := lookup()
+		if err == nil {
+			return v, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// latestEmbeddedVersion returns the latest version of the module in the
+// release table embedded at build time.
+func latestEmbeddedVersion(modulePath string) (string, error) {
+	if modulePath == cliModulePath {
+		return "", errNoEmbeddedCliRelease
+	}
+	latest := maxRelease(embeddedReleases()[modulePath])
+	if latest == "" {
+		return "", fmt.Errorf("no embedded release of %s", modulePath)
+	}
+	return strings.TrimPrefix(latest, "v"), nil
+}
+
+// embeddedReleases returns the embedded release table.
+func embeddedReleases() map[string][]string {
+	var table map[string][]string
+	if err := json.Unmarshal(releasesJSON, &table); err != nil {
+		// the table is validated by the tests
+		return nil
+	}
+	return table
+}
+
+// goProxies returns the module proxies of GOPROXY for the module followed by
+// the module download cache, which has the layout of a file:// proxy.
+// GOPROXY=off disables the network proxies, direct entries are skipped. Like
+// the go command, modules matching GONOPROXY, or GOPRIVATE if it is unset,
+// are not fetched from network proxies.
+func goProxies(modulePath string) []string {
+	env := os.Getenv("GOPROXY")
+	if env == "" {
+		env = "https://proxy.golang.org,direct"
+	}
+	noProxy, ok := os.LookupEnv("GONOPROXY")
+	if !ok {
+		noProxy = os.Getenv("GOPRIVATE")
+	}
+	if module.MatchPrefixPatterns(noProxy, modulePath) {
+		env = "off"
+	}
+
+	var proxies []string
+	for _, p := range strings.FieldsFunc(env, func(r rune) bool { return r == ',' || r == '|' }) {
+		if p = strings.TrimSpace(p); p != "" && p != "direct" && p != "off" {
+			proxies = append(proxies, strings.TrimSuffix(p, "/"))
+		}
+	}
+	if dir := goModCache(); dir != "" {
+		proxies = append(proxies, "file://"+filepath.ToSlash(filepath.Join(dir, "cache", "download")))
+	}
+	return proxies
+}
+
+// goModCache returns the module cache directory like go env GOMODCACHE.
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	if homeDir == "" {
+		return ""
+	}
+	return filepath.Join(homeDir, "go", "pkg", "mod")
+}
+
+// latestVersionByProxy returns the latest release of the module from the
+// first module proxy that knows it. The major versions are probed in order,
+// e.g. github.com/gofiber/fiber, github.com/gofiber/fiber/v2, ..., until one
+// above the majors of the embedded release table is not found. Prereleases
+// are ignored.
+func latestVersionByProxy(modulePath string) (string, error) {
+	var errs []error
+	for _, proxy := range goProxies(modulePath) {
+		latest, err := proxyLatest(proxy, modulePath)
+		if err == nil {
+			return strings.TrimPrefix(latest, "v"), nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", proxy, err))
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no module proxy configured")
+	}
+	return "", errors.Join(errs...)
+}
+
+// maxProxyMajor is the highest major version probed on a module proxy, it
+// bounds the requests made to a proxy that answers for every module path.
+const maxProxyMajor = 20
+
+func proxyLatest(proxy, modulePath string) (string, error) {
+	// the module cache only holds the major versions that were downloaded, so
+	// every major version of the embedded table is probed before stopping at
+	// the first missing one above them
+	known := 1
+	if v := maxRelease(embeddedReleases()[modulePath]); v != "" {
+		if n, err := strconv.Atoi(strings.TrimPrefix(modsemver.Major(v), "v")); err == nil {
+			known = n
+		}
+	}
+
+	latest := ""
+	found := false
+	for major := 1; major <= maxProxyMajor; major++ {
+		path := modulePath
+		if major > 1 {
+			path += "/v" + strconv.Itoa(major)
+		}
+
+		versions, err := proxyList(proxy, path)
+		if errors.Is(err, errModuleNotFound) {
+			// modules starting at v2 have no path without suffix
+			if major <= known {
+				continue
+			}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		found = true
+		if v := maxRelease(versions); v != "" {
+			latest = v
+		}
+	}
+
+	if !found {
+		return "", fmt.Errorf("%s: %w", modulePath, errModuleNotFound)
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no release of %s", modulePath)
+	}
+	return latest, nil
+}
+
+// proxyList returns the versions of the module listed by the proxy.
+func proxyList(proxy, modulePath string) ([]string, error) {
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("escape module path: %w", err)
+	}
+
+	var b []byte
+	if strings.HasPrefix(proxy, "file://") {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parse proxy url: %w", err)
+		}
+		b, err = os.ReadFile(filepath.Join(filepath.FromSlash(u.Path), filepath.FromSlash(escaped), "@v", "list"))
+		if os.IsNotExist(err) {
+			return nil, errModuleNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read version list: %w", err)
+		}
+	} else {
+		res, err := httpGet(proxy+"/"+escaped+"/@v/list", nil)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case res.status == http.StatusNotFound || res.status == http.StatusGone:
+			return nil, errModuleNotFound
+		case res.status != http.StatusOK:
+			return nil, fmt.Errorf("unexpected status %d", res.status)
+		}
+		b = res.body
+	}
+
+	return strings.Fields(string(b)), nil
+}
+
+// maxRelease returns the highest version that is not a prerelease.
+func maxRelease(versions []string) string {
+	latest := ""
+	for _, v := range versions {
+		if !modsemver.IsValid(v) || modsemver.Prerelease(v) != "" {
+			continue
+		}
+		if latest == "" || modsemver.Compare(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// httpResponse is the result of httpGet.
+type httpResponse struct {
+	header http.Header
+	body   []byte
+	status int
+}
+
+// httpGet sends a GET request with the given header. The default transport
+// honours HTTPS_PROXY, HTTP_PROXY and NO_PROXY, credentials in the URL are
+// sent as basic authentication.
+func httpGet(url string, header http.Header) (_ *httpResponse, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create http request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	defer func() {
+		if cerr := res.Body.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	return &httpResponse{header: res.Header, body: body, status: res.StatusCode}, nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProxyList(t *testing.T, dir, modulePath, list string) {
+	t.Helper()
+	listDir := filepath.Join(dir, filepath.FromSlash(modulePath), "@v")
+	require.NoError(t, os.MkdirAll(listDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(listDir, "list"), []byte(list), 0o600))
+}
+
+func Test_Releases_Embedded(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceEmbedded)
+
+	v, err := LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", v)
+
+	_, err = LatestCliVersion()
+	require.ErrorIs(t, err, errNoEmbeddedCliRelease)
+}
+
+func Test_Releases_Proxy(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceProxy)
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	t.Run("file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProxyList(t, dir, "github.com/gofiber/fiber", "v1.14.6\n")
+		writeProxyList(t, dir, "github.com/gofiber/fiber/v2", "v2.0.5\nv2.0.6\n")
+		writeProxyList(t, dir, "github.com/gofiber/fiber/v3", "v3.0.0-beta.1\n")
+		t.Setenv("GOPROXY", "off|file://"+filepath.ToSlash(dir))
+
+		v, err := LatestFiberVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "2.0.6", v)
+
+		_, err = LatestCliVersion()
+		require.ErrorIs(t, err, errModuleNotFound)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterRegexpResponder(http.MethodGet, regexp.MustCompile(`^https://proxy\.example\.com/`), httpmock.NewStringResponder(http.StatusNotFound, "not found"))
+		httpmock.RegisterResponder(http.MethodGet, "https://proxy.example.com/github.com/gofiber/cli/@v/list", httpmock.NewStringResponder(http.StatusOK, "v0.0.1\nv0.0.3\nv0.0.2\n"))
+		t.Setenv("GOPROXY", "https://proxy.example.com/,direct")
+
+		v, err := LatestCliVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "0.0.3", v)
+	})
+
+	t.Run("private", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		t.Setenv("GOPROXY", "https://proxy.example.com")
+		t.Setenv("GONOPROXY", "github.com/gofiber")
+
+		_, err := LatestCliVersion()
+		require.ErrorIs(t, err, errModuleNotFound)
+		assert.Zero(t, httpmock.GetTotalCallCount())
+	})
+
+	t.Run("module cache", func(t *testing.T) {
+		cache := t.TempDir()
+		writeProxyList(t, filepath.Join(cache, "cache", "download"), "github.com/gofiber/fiber/v2", "v2.52.0\n")
+		t.Setenv("GOMODCACHE", cache)
+		t.Setenv("GOPROXY", "off")
+
+		v, err := LatestFiberVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "2.52.0", v)
+	})
+
+	t.Run("sparse module cache", func(t *testing.T) {
+		cache := t.TempDir()
+		writeProxyList(t, filepath.Join(cache, "cache", "download"), "github.com/gofiber/fiber/v3", "v3.0.0\nv3.1.0\n")
+		t.Setenv("GOMODCACHE", cache)
+		t.Setenv("GOPROXY", "off")
+
+		v, err := LatestFiberVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "3.1.0", v)
+	})
+
+	t.Run("newer major", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProxyList(t, dir, "github.com/gofiber/fiber/v4", "v4.0.0\n")
+		writeProxyList(t, dir, "github.com/gofiber/fiber/v5", "v5.0.1\n")
+		t.Setenv("GOPROXY", "file://"+filepath.ToSlash(dir))
+
+		v, err := LatestFiberVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "5.0.1", v)
+	})
+
+	t.Run("major cap", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		// a proxy that lists a version for every major version path
+		httpmock.RegisterRegexpResponder(http.MethodGet, regexp.MustCompile(`^https://proxy\.example\.com/github\.com/gofiber/fiber/`), httpmock.NewStringResponder(http.StatusOK, "v3.0.0\n"))
+		t.Setenv("GOPROXY", "https://proxy.example.com")
+
+		v, err := LatestFiberVersion()
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.0", v)
+		assert.Equal(t, maxProxyMajor, httpmock.GetTotalCallCount())
+	})
+}
+
+func Test_Releases_Auto(t *testing.T) {
+	t.Setenv(versionSourceEnv, "")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, latestVersionURL, httpmock.NewErrorResponder(errors.New("network error")))
+
+	// GitHub and the proxies fail, the embedded table answers
+	v, err := LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", v)
+
+	httpmock.RegisterResponder(http.MethodGet, latestVersionURL, httpmock.NewBytesResponder(200, fakeVersionResponse))
+	v, err = LatestFiberVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.6", v)
+
+	t.Setenv(versionSourceEnv, "ftp")
+	_, err = LatestFiberVersion()
+	require.ErrorContains(t, err, `invalid version source "ftp"`)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
@@ -63,14 +64,20 @@ var rc = rootConfig{
 }
 
 type rootConfig struct {
-	CliVersionCheckInterval int64 `json:"cli_version_check_interval"`
-	CliVersionCheckedAt     int64 `json:"cli_version_checked_at"`
+	GitHubReleases          map[string]githubRelease `json:"github_releases,omitempty"`
+	GitHubAPIURL            string                   `json:"github_api_url,omitempty"`
+	MigrateExclude          []string                 `json:"migrate_exclude,omitempty"`
+	CliVersionCheckInterval int64                    `json:"cli_version_check_interval"`
+	CliVersionCheckedAt     int64                    `json:"cli_version_checked_at"`
 }
 
 func init() {
 	// Set the long description dynamically with the current version
 	rootCmd.Long = getLongDescription()
 
+	rootCmd.PersistentFlags().StringVar(&versionSource, "version-source", "",
+		"Source of the latest released versions: auto|github|proxy|embedded (default $"+versionSourceEnv+" or auto)")
+
 	rootCmd.AddCommand(
 		versionCmd, newCmd, devCmd, upgradeCmd, migrateCmd,
 	)
@@ -116,6 +123,11 @@ func checkCliVersion(cmd *cobra.Command) {
 	}
 
 	cliLatestVersion, err := LatestCliVersion()
+	if errors.Is(err, errNoEmbeddedCliRelease) {
+		cmd.Println(termenv.String("Skipping the fiber cli update check: " + err.Error()).Foreground(termenv.ANSIBrightYellow))
+		updateVersionCheckedAt()
+		return
+	}
 	if err != nil {
 		return
 	}
@@ -75,6 +75,8 @@ func Test_Root_RootPersistentPostRun(t *testing.T) {
 }
 
 func Test_Root_CheckCliVersion(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceGitHub)
+
 	at, b := setupRootCmd(t)
 
 	rc.CliVersionCheckedAt = 0
@@ -107,6 +109,29 @@ func Test_Root_CheckCliVersion(t *testing.T) {
 	rc.CliVersionCheckedAt = 0
 }
 
+func Test_Root_CheckCliVersion_Embedded(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceEmbedded)
+
+	at, b := setupRootCmd(t)
+
+	rc.CliVersionCheckedAt = 0
+	upgraded = false
+
+	origHome := homeDir
+	tempHome := setupHomeDir(t, "CheckCliVersionEmbedded")
+	homeDir = tempHome
+	defer func() {
+		homeDir = origHome
+		teardownHomeDir(tempHome)
+	}()
+
+	checkCliVersion(rootCmd)
+
+	at.Contains(b.String(), "Skipping the fiber cli update check")
+	at.InDelta(time.Now().Unix(), rc.CliVersionCheckedAt, 1)
+	rc.CliVersionCheckedAt = 0
+}
+
 func Test_Root_NeedCheckCliVersion(t *testing.T) {
 	rc.CliVersionCheckedAt = 0
 	upgraded = false
@@ -12,6 +12,8 @@ import (
 )
 
 func Test_Upgrade_upgradeRunE(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceGitHub)
+
 	at := assert.New(t)
 
 	b := &bytes.Buffer{}
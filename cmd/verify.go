@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+
+	"github.com/gofiber/cli/cmd/internal"
+)
+
+const (
+	verifyBuild = "build"
+	verifyTest  = "test"
+)
+
+// verifyErrorRegexp matches the file positions in the output of go build,
+// go vet and go test, e.g. "./main.go:12:5: undefined: x".
+var verifyErrorRegexp = regexp.MustCompile(`^(?:vet: )?\s*([^\s:]+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// verifyResult is the outcome of a verification command in a module.
+type verifyResult struct {
+	Dir     string        `json:"dir"`
+	Command string        `json:"command"`
+	Output  string        `json:"output,omitempty"`
+	Errors  []verifyError `json:"errors,omitempty"`
+	Passed  bool          `json:"passed"`
+}
+
+// verifyError is an error reported for a position in a Go file together with
+// the migrations that changed the file.
+type verifyError struct {
+	File       string   `json:"file"`
+	Message    string   `json:"message"`
+	Migrations []string `json:"migrations,omitempty"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column,omitempty"`
+}
+
+// validVerifyMode returns an error for unknown --verify values.
+func validVerifyMode(mode string) error {
+	switch mode {
+	case "", verifyBuild, verifyTest:
+		return nil
+	default:
+		return fmt.Errorf("invalid verify mode %q, use %s or %s", mode, verifyBuild, verifyTest)
+	}
+}
+
+// runVerify runs go build and go vet, and go test for the test mode, in every
+// module directory. The remaining commands of a module are skipped once one
+// fails. Errors in files changed by the migration are attributed to the
+// migration steps recorded by the overlay.
+func runVerify(dirs []string, mode string, overlay *internal.Overlay) []verifyResult {
+	commands := [][]string{
+		{"go", "build", "./..."},
+		{"go", "vet", "./..."},
+	}
+	if mode == verifyTest {
+		commands = append(commands, []string{"go", "test", "./..."})
+	}
+	touched := touchedBy(overlay)
+
+	var results []verifyResult
+	for _, dir := range dirs {
+		for _, args := range commands {
+			cmd := execCommand(args[0], args[1:]...) // #nosec G204 -- commands are controlled
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+
+			result := verifyResult{Dir: dir, Command: strings.Join(args, " "), Passed: err == nil}
+			if err != nil {
+				result.Output = string(out)
+				result.Errors = parseVerifyErrors(dir, string(out), touched)
+			}
+			results = append(results, result)
+
+			if err != nil {
+				break
+			}
+		}
+	}
+	return results
+}
+
+// touchedBy maps the files changed by the migration to the migration steps
+// that changed them.
+func touchedBy(overlay *internal.Overlay) map[string][]string {
+	touched := make(map[string][]string)
+	if overlay == nil {
+		return touched
+	}
+	for _, stage := range overlay.Stages() {
+		for _, path := range overlay.Touched(stage) {
+			touched[path] = append(touched[path], stage)
+		}
+	}
+	return touched
+}
+
+func parseVerifyErrors(dir, out string, touched map[string][]string) []verifyError {
+	var errs []verifyError
+	for _, line := range strings.Split(out, "\n") {
+		m := verifyErrorRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		path := m[1]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, filepath.FromSlash(path))
+		}
+		lineNo, _ := strconv.Atoi(m[2]) //nolint:errcheck // matched digits
+		column, _ := strconv.Atoi(m[3]) //nolint:errcheck // empty if absent
+		errs = append(errs, verifyError{
+			File:       path,
+			Line:       lineNo,
+			Column:     column,
+			Message:    m[4],
+			Migrations: touched[path],
+		})
+	}
+	return errs
+}
+
+// printVerify prints the verification results and returns an error if a
+// command failed.
+func printVerify(cmd *cobra.Command, wd string, results []verifyResult) error {
+	failed := 0
+	for _, r := range results {
+		status := termenv.String("ok").Foreground(termenv.ANSIBrightGreen)
+		if !r.Passed {
+			failed++
+			status = termenv.String("failed").Foreground(termenv.ANSIBrightRed)
+		}
+		cmd.Printf("  %s in %s: %s\n", r.Command, relPath(wd, r.Dir), status)
+
+		if r.Passed {
+			continue
+		}
+		if len(r.Errors) == 0 {
+			cmd.Print(indent(r.Output, "    "))
+			continue
+		}
+		for _, e := range r.Errors {
+			pos := fmt.Sprintf("%s:%d", relPath(wd, e.File), e.Line)
+			if e.Column > 0 {
+				pos += fmt.Sprintf(":%d", e.Column)
+			}
+			cmd.Printf("      %s: %s", pos, e.Message)
+			if len(e.Migrations) > 0 {
+				cmd.Printf(" (changed by %s)", strings.Join(e.Migrations, ", "))
+			}
+			cmd.Println()
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("migration was applied but verification failed: %d of %d check(s) failed", failed, len(results))
+	}
+	cmd.Println(termenv.String("Verification passed").Foreground(termenv.ANSIBrightGreen))
+	return nil
+}
+
+// indent prefixes every non-empty line of s.
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// moduleDirs returns the directories of the modules.
+func moduleDirs(modules []fiberModule) []string {
+	dirs := make([]string, 0, len(modules))
+	for _, m := range modules {
+		dirs = append(dirs, m.dir)
+	}
+	return dirs
+}
@@ -1,25 +1,50 @@
 package cmd
 
 import (
-	"context"
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
-	"time"
+	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/cobra"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	modsemver "golang.org/x/mod/semver"
 )
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print the local and released version number of fiber",
-	Run:   versionRun,
+func newVersionCmd() *cobra.Command {
+	var output string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the local and released version number of fiber",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if asJSON {
+				output = outputJSON
+			}
+			switch output {
+			case outputText, "":
+				versionRun(cmd, nil)
+				return nil
+			case outputJSON, outputYAML:
+				return writeVersionReport(cmd.OutOrStdout(), newVersionReport("."), output)
+			default:
+				return fmt.Errorf("invalid output format %q, use %s, %s or %s", output, outputText, outputJSON, outputYAML)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputText, "Output format of the environment report: text|json|yaml")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the environment report as JSON, same as --output json")
+
+	return cmd
 }
 
+var versionCmd = newVersionCmd()
+
 func versionRun(cmd *cobra.Command, _ []string) {
 	var (
 		cur, latest string
@@ -40,73 +65,105 @@ func versionRun(cmd *cobra.Command, _ []string) {
 }
 
 var (
-	currentVersionRegexp = regexp.MustCompile(`github\.com/gofiber/fiber[^\n]*? (.*)\n`)
-	currentVersionFile   = "go.mod"
+	fiberModulePathRegexp = regexp.MustCompile(`^github\.com/gofiber/fiber(/v\d+)?$`)
+	currentVersionFile    = "go.mod"
 )
 
-func currentVersionFromFile(path string) (string, error) {
+// fiberRequirement is the Fiber module a go.mod file requires.
+type fiberRequirement struct {
+	Path    string // required module path, e.g. github.com/gofiber/fiber/v2
+	Version string // version after applying replace directives
+	Dir     string // local directory the module is replaced with
+}
+
+// fiberRequirementFromFile parses the go.mod file at path and returns the
+// Fiber module it requires. If several major versions are required, the
+// highest one is returned. Replace directives are honoured.
+func fiberRequirementFromFile(path string) (fiberRequirement, error) {
 	b, err := os.ReadFile(filepath.Clean(path))
 	if err != nil {
-		return "", fmt.Errorf("read current version file: %w", err)
+		return fiberRequirement{}, fmt.Errorf("read current version file: %w", err)
 	}
-
-	if submatch := currentVersionRegexp.FindSubmatch(b); len(submatch) == 2 {
-		return string(submatch[1]), nil
+	mf, err := modfile.Parse(path, b, nil)
+	if err != nil {
+		return fiberRequirement{}, fmt.Errorf("parse %s: %w", path, err)
 	}
 
-	return "", errors.New("github.com/gofiber/fiber was not found in go.mod")
-}
-
-func currentVersion() (string, error) {
-	return currentVersionFromFile(currentVersionFile)
-}
-
-var latestVersionRegexp = regexp.MustCompile(`"name":\s*?"v(.*?)"`)
+	var req *modfile.Require
+	for _, r := range mf.Require {
+		if !fiberModulePathRegexp.MatchString(r.Mod.Path) {
+			continue
+		}
+		if req == nil || modsemver.Compare(r.Mod.Version, req.Mod.Version) > 0 {
+			req = r
+		}
+	}
+	if req == nil {
+		return fiberRequirement{}, fmt.Errorf("github.com/gofiber/fiber is not required in %s", path)
+	}
 
-// LatestFiberVersion retrieves the most recent Fiber release version from GitHub.
-func LatestFiberVersion() (string, error) {
-	return latestVersionByURL("https://api.github.com/repos/gofiber/fiber/releases/latest")
-}
+	fr := fiberRequirement{Path: req.Mod.Path, Version: req.Mod.Version}
+	for _, r := range mf.Replace {
+		if r.Old.Path != req.Mod.Path || (r.Old.Version != "" && r.Old.Version != req.Mod.Version) {
+			continue
+		}
+		if modfile.IsDirectoryPath(r.New.Path) {
+			fr.Version, fr.Dir = "", r.New.Path
+		} else {
+			fr.Version, fr.Dir = r.New.Version, ""
+		}
+		// a replace for the exact version takes precedence
+		if r.Old.Version != "" {
+			break
+		}
+	}
 
-// LatestCliVersion retrieves the latest Fiber CLI release version from GitHub.
-func LatestCliVersion() (string, error) {
-	return latestVersionByURL("https://api.github.com/repos/gofiber/cli/releases/latest")
+	return fr, nil
 }
 
-func latestVersionByURL(url string) (string, error) {
-	var (
-		res *http.Response
-		b   []byte
-	)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// currentVersionFromFile returns the Fiber version required by the go.mod
+// file at path, including pseudo-versions.
+func currentVersionFromFile(path string) (string, error) {
+	fr, err := fiberRequirementFromFile(path)
 	if err != nil {
-		return "", fmt.Errorf("create http request: %w", err)
+		return "", err
+	}
+	if fr.Dir != "" {
+		return "", fmt.Errorf("%s is replaced by the local directory %s, its version is unknown", fr.Path, fr.Dir)
 	}
+	return fr.Version, nil
+}
 
-	client := &http.Client{}
-	res, err = client.Do(req)
+// projectFiberVersion returns the released Fiber version required by the
+// go.mod file at path. Pseudo-versions and local replacements are rejected
+// since migrations are selected by release version.
+func projectFiberVersion(path string) (*semver.Version, error) {
+	versionS, err := currentVersionFromFile(path)
 	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
+		return nil, err
 	}
-
-	defer func() {
-		if cerr := res.Body.Close(); cerr != nil && err == nil {
-			err = cerr
-		}
-	}()
-
-	b, err = io.ReadAll(res.Body)
+	if module.IsPseudoVersion(versionS) {
+		return nil, fmt.Errorf("github.com/gofiber/fiber %s in %s is a pseudo-version, require a released version first", versionS, path)
+	}
+	version, err := semver.NewVersion(strings.TrimPrefix(versionS, "v"))
 	if err != nil {
-		return "", fmt.Errorf("read response body: %w", err)
+		return nil, fmt.Errorf("invalid fiber version %q in %s: %w", versionS, path, err)
 	}
+	return version, nil
+}
 
-	if submatch := latestVersionRegexp.FindSubmatch(b); len(submatch) == 2 {
-		return string(submatch[1]), nil
-	}
+func currentVersion() (string, error) {
+	return currentVersionFromFile(currentVersionFile)
+}
 
-	return "", errors.New("no version found in github response body")
+// LatestFiberVersion retrieves the most recent Fiber release version from
+// the source selected with --version-source, see release.latest.
+func LatestFiberVersion() (string, error) {
+	return fiberRelease.latest()
+}
+
+// LatestCliVersion retrieves the latest Fiber CLI release version from the
+// source selected with --version-source.
+func LatestCliVersion() (string, error) {
+	return cliRelease.latest()
 }
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	modsemver "golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// versionReport is the environment report printed by fiber version
+// --output json|yaml for bug reports and dashboards.
+type versionReport struct {
+	CLI     cliReport      `json:"cli" yaml:"cli"`
+	Latest  latestReport   `json:"latest" yaml:"latest"`
+	Modules []moduleReport `json:"modules" yaml:"modules"`
+	Errors  []string       `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type cliReport struct {
+	Version        string `json:"version" yaml:"version"`
+	Commit         string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	CommitTime     string `json:"commit_time,omitempty" yaml:"commit_time,omitempty"`
+	GoVersion      string `json:"go_version" yaml:"go_version"`
+	OS             string `json:"os" yaml:"os"`
+	Arch           string `json:"arch" yaml:"arch"`
+	Recommendation string `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+	Modified       bool   `json:"modified,omitempty" yaml:"modified,omitempty"`
+}
+
+type latestReport struct {
+	Fiber string `json:"fiber,omitempty" yaml:"fiber,omitempty"`
+	CLI   string `json:"cli,omitempty" yaml:"cli,omitempty"`
+}
+
+type moduleReport struct {
+	Dir          string             `json:"dir" yaml:"dir"`
+	Path         string             `json:"path" yaml:"path"`
+	GoVersion    string             `json:"go_version,omitempty" yaml:"go_version,omitempty"`
+	FiberPath    string             `json:"fiber_path" yaml:"fiber_path"`
+	FiberVersion string             `json:"fiber_version,omitempty" yaml:"fiber_version,omitempty"`
+	FiberReplace string             `json:"fiber_replace,omitempty" yaml:"fiber_replace,omitempty"`
+	Dependencies []dependencyReport `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	// Recommendation is the command that brings the module to the latest
+	// Fiber release, a migration if the major version differs.
+	Recommendation string `json:"recommendation,omitempty" yaml:"recommendation,omitempty"`
+}
+
+// dependencyReport is a required gofiber module other than Fiber, e.g. a
+// contrib middleware or a storage driver.
+type dependencyReport struct {
+	Path     string `json:"path" yaml:"path"`
+	Version  string `json:"version" yaml:"version"`
+	Indirect bool   `json:"indirect,omitempty" yaml:"indirect,omitempty"`
+}
+
+// newVersionReport collects the report for the Fiber modules below root.
+// Failed lookups are listed in Errors instead of failing the report.
+func newVersionReport(root string) versionReport {
+	r := versionReport{
+		CLI: cliReport{
+			Version:   getVersion(),
+			GoVersion: runtime.Version(),
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+		},
+		Modules: []moduleReport{},
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				r.CLI.Commit = s.Value
+			case "vcs.time":
+				r.CLI.CommitTime = s.Value
+			case "vcs.modified":
+				r.CLI.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	var err error
+	if r.Latest.Fiber, err = LatestFiberVersion(); err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("latest fiber version: %v", err))
+	}
+	if r.Latest.CLI, err = LatestCliVersion(); err != nil {
+		r.Errors = append(r.Errors, fmt.Sprintf("latest cli version: %v", err))
+	}
+	if olderRelease(r.CLI.Version, r.Latest.CLI) {
+		r.CLI.Recommendation = "fiber upgrade"
+	}
+
+	dirs, err := fiberModuleDirs(root)
+	if err != nil {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	for _, dir := range dirs {
+		m, err := newModuleReport(root, dir, r.Latest.Fiber)
+		if err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			continue
+		}
+		r.Modules = append(r.Modules, m)
+	}
+
+	return r
+}
+
+func newModuleReport(root, dir, latestFiber string) (moduleReport, error) {
+	path := filepath.Join(dir, "go.mod")
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return moduleReport{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	mf, err := modfile.Parse(path, b, nil)
+	if err != nil {
+		return moduleReport{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	fr, err := fiberRequirementFromFile(path)
+	if err != nil {
+		return moduleReport{}, err
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		rel = dir
+	}
+	m := moduleReport{
+		Dir:          filepath.ToSlash(rel),
+		FiberPath:    fr.Path,
+		FiberVersion: fr.Version,
+		FiberReplace: fr.Dir,
+	}
+	if mf.Module != nil {
+		m.Path = mf.Module.Mod.Path
+	}
+	if mf.Go != nil {
+		m.GoVersion = mf.Go.Version
+	}
+	for _, req := range mf.Require {
+		if strings.HasPrefix(req.Mod.Path, "github.com/gofiber/") && !fiberModulePathRegexp.MatchString(req.Mod.Path) {
+			m.Dependencies = append(m.Dependencies, dependencyReport{
+				Path:     req.Mod.Path,
+				Version:  req.Mod.Version,
+				Indirect: req.Indirect,
+			})
+		}
+	}
+
+	if fr.Dir == "" && olderRelease(fr.Version, latestFiber) {
+		latest := "v" + strings.TrimPrefix(latestFiber, "v")
+		if modsemver.Major(fr.Version) != modsemver.Major(latest) {
+			m.Recommendation = "fiber migrate --to " + strings.TrimPrefix(latest, "v")
+		} else {
+			m.Recommendation = "go get " + fr.Path + "@" + latest
+		}
+	}
+
+	return m, nil
+}
+
+// olderRelease reports whether version is older than latest. Both may omit
+// the "v" prefix, versions that are not semantic versions are never older.
+func olderRelease(version, latest string) bool {
+	version = "v" + strings.TrimPrefix(version, "v")
+	latest = "v" + strings.TrimPrefix(latest, "v")
+	return modsemver.IsValid(version) && modsemver.IsValid(latest) && modsemver.Compare(version, latest) < 0
+}
+
+// writeVersionReport writes the report to w in the output format, json or
+// yaml.
+func writeVersionReport(w io.Writer, r versionReport, output string) error {
+	if output == outputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("encode version report: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("encode version report: %w", err)
+		}
+		return nil
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode version report: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/jarcoal/httpmock"
@@ -12,6 +14,8 @@ import (
 )
 
 func Test_Version_Printer(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceGitHub)
+
 	at := assert.New(t)
 	t.Run("success", func(t *testing.T) {
 		httpmock.Activate()
@@ -36,6 +40,80 @@ func Test_Version_Printer(t *testing.T) {
 	})
 }
 
+func Test_Version_Report(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceEmbedded)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/app
+
+go 1.22
+
+require (
+	github.com/gofiber/contrib/jwt v1.0.10
+	github.com/gofiber/fiber/v2 v2.52.0
+	github.com/gofiber/utils v1.1.0 // indirect
+)
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "svc"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "svc", "go.mod"), []byte(`module example.com/svc
+
+require github.com/gofiber/fiber/v3 v3.0.0
+`), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tool"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool", "go.mod"), []byte("module example.com/tool\n"), 0o600))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	out, err := runCobraCmd(newVersionCmd(), "--json")
+	require.NoError(t, err)
+
+	var r versionReport
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	assert.Equal(t, getVersion(), r.CLI.Version)
+	assert.NotEmpty(t, r.CLI.GoVersion)
+	assert.Equal(t, "3.0.0", r.Latest.Fiber)
+	assert.Empty(t, r.Latest.CLI)
+	require.Len(t, r.Errors, 1)
+	assert.Contains(t, r.Errors[0], "latest cli version")
+
+	require.Len(t, r.Modules, 2)
+	assert.Equal(t, moduleReport{
+		Dir:          ".",
+		Path:         "example.com/app",
+		GoVersion:    "1.22",
+		FiberPath:    "github.com/gofiber/fiber/v2",
+		FiberVersion: "v2.52.0",
+		Dependencies: []dependencyReport{
+			{Path: "github.com/gofiber/contrib/jwt", Version: "v1.0.10"},
+			{Path: "github.com/gofiber/utils", Version: "v1.1.0", Indirect: true},
+		},
+		Recommendation: "fiber migrate --to 3.0.0",
+	}, r.Modules[0])
+	assert.Equal(t, "svc", r.Modules[1].Dir)
+	assert.Empty(t, r.Modules[1].Recommendation)
+
+	out, err = runCobraCmd(newVersionCmd(), "-o", "yaml")
+	require.NoError(t, err)
+	assert.Contains(t, out, "fiber_path: github.com/gofiber/fiber/v2")
+	assert.Contains(t, out, "recommendation: fiber migrate --to 3.0.0")
+
+	_, err = runCobraCmd(newVersionCmd(), "-o", "xml")
+	require.ErrorContains(t, err, `invalid output format "xml"`)
+}
+
+func Test_Version_OlderRelease(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, olderRelease("2.52.0", "3.0.0"))
+	assert.True(t, olderRelease("v3.0.0", "3.0.1"))
+	assert.False(t, olderRelease("3.0.0", "3.0.0"))
+	assert.False(t, olderRelease("unknown", "3.0.0"))
+	assert.False(t, olderRelease("3.0.0", ""))
+}
+
 func Test_Version_Current(t *testing.T) {
 	at := assert.New(t)
 
@@ -94,6 +172,109 @@ require (
 	})
 }
 
+func Test_Version_Current_Modfile(t *testing.T) {
+	at := assert.New(t)
+
+	t.Run("ignores other modules and comments", func(t *testing.T) {
+		content := `module fiber-demo
+
+go 1.21
+
+// github.com/gofiber/fiber/v2 v2.0.0
+require (
+	github.com/gofiber/fiber-contrib v1.0.0 // indirect
+	github.com/gofiber/fiber/v2 v2.52.0
+)
+`
+		setupCurrentVersionFile(content)
+		defer teardownCurrentVersionFile()
+
+		v, err := currentVersion()
+		require.NoError(t, err)
+		at.Equal("v2.52.0", v)
+	})
+
+	t.Run("honours replace", func(t *testing.T) {
+		content := `module fiber-demo
+
+go 1.21
+
+require github.com/gofiber/fiber/v2 v2.0.6
+
+replace github.com/gofiber/fiber/v2 => github.com/gofiber/fiber/v2 v2.52.5
+`
+		setupCurrentVersionFile(content)
+		defer teardownCurrentVersionFile()
+
+		v, err := currentVersion()
+		require.NoError(t, err)
+		at.Equal("v2.52.5", v)
+	})
+
+	t.Run("local replace", func(t *testing.T) {
+		content := `module fiber-demo
+
+go 1.21
+
+require github.com/gofiber/fiber/v2 v2.0.6
+
+replace github.com/gofiber/fiber/v2 => ../fiber
+`
+		setupCurrentVersionFile(content)
+		defer teardownCurrentVersionFile()
+
+		_, err := currentVersion()
+		require.Error(t, err)
+		at.Contains(err.Error(), "local directory ../fiber")
+	})
+
+	t.Run("highest major", func(t *testing.T) {
+		content := `module fiber-demo
+
+go 1.21
+
+require (
+	github.com/gofiber/fiber/v2 v2.52.0
+	github.com/gofiber/fiber/v3 v3.0.0
+)
+`
+		setupCurrentVersionFile(content)
+		defer teardownCurrentVersionFile()
+
+		v, err := currentVersion()
+		require.NoError(t, err)
+		at.Equal("v3.0.0", v)
+	})
+}
+
+func Test_ProjectFiberVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, require string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		content := "module demo\n\ngo 1.21\n\n" + require + "\n"
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	v, err := projectFiberVersion(write("released", "require github.com/gofiber/fiber/v2 v2.0.6"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.6", v.String())
+
+	_, err = projectFiberVersion(write("pseudo", "require github.com/gofiber/fiber/v2 v2.0.0-20200926082917-55763e7e6ee3"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pseudo-version")
+
+	_, err = projectFiberVersion(write("missing", "require github.com/jarcoal/httpmock v1.0.6"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not required")
+
+	_, err = projectFiberVersion(filepath.Join(dir, "absent"))
+	require.Error(t, err)
+}
+
 func setupCurrentVersionFile(content ...string) {
 	currentVersionFile = "current-version"
 	if len(content) > 0 {
@@ -111,6 +292,8 @@ func teardownCurrentVersionFile() {
 }
 
 func Test_Version_Latest(t *testing.T) {
+	t.Setenv(versionSourceEnv, versionSourceGitHub)
+
 	at := assert.New(t)
 	t.Run("http get error", func(t *testing.T) {
 		httpmock.Activate()